@@ -0,0 +1,200 @@
+// Market breadth signals from sector constituents.
+
+package analysis
+
+import (
+	"math"
+
+	"sector-analyzer/data"
+)
+
+// breadthWindow is the number of recent sessions over which advances,
+// declines and new highs and lows are counted.
+const breadthWindow = 20
+
+// yearSessions is the number of sessions in a 52-week high or low.
+const yearSessions = 252
+
+// SectorBreadth describes how broadly a sector's constituents participate in
+// its move. Each constituent counts equally, whatever its weight in the ETF.
+// AboveMA50 and AboveMA200 are fractions of the members with enough history;
+// AdvanceDecline is advancing over declining member-sessions in the last
+// breadthWindow sessions (nil when none declined); NetNewHighs is members
+// that set a 52-week high in that window less those that set a low, over
+// members.
+type SectorBreadth struct {
+	Members        int      `json:"members"`
+	AboveMA50      *float64 `json:"above_ma50"`
+	AboveMA200     *float64 `json:"above_ma200"`
+	Advances       int      `json:"advances"`
+	Declines       int      `json:"declines"`
+	AdvanceDecline *float64 `json:"advance_decline"`
+	NewHighs       int      `json:"new_highs"`
+	NewLows        int      `json:"new_lows"`
+	NetNewHighs    *float64 `json:"net_new_highs"`
+}
+
+// CalculateBreadth computes breadth for each sector with holdings, using the
+// constituents' price series. Constituents without prices are skipped.
+func CalculateBreadth(holdings map[string][]data.Holding, prices map[string]data.PriceSeries) map[string]SectorBreadth {
+	breadth := make(map[string]SectorBreadth)
+
+	for sector, members := range holdings {
+		var b SectorBreadth
+		var above50, with50, above200, with200, withYear int
+
+		for _, h := range members {
+			series, ok := prices[h.Ticker]
+			if !ok || len(series) < breadthWindow+1 {
+				continue
+			}
+			b.Members++
+			last := ReturnPrice(series[len(series)-1])
+
+			if len(series) >= 50 {
+				with50++
+				if last > movingAverage(series, 50) {
+					above50++
+				}
+			}
+			if len(series) >= 200 {
+				with200++
+				if last > movingAverage(series, 200) {
+					above200++
+				}
+			}
+
+			for i := len(series) - breadthWindow; i < len(series); i++ {
+				change := ReturnPrice(series[i]) - ReturnPrice(series[i-1])
+				if change > 0 {
+					b.Advances++
+				} else if change < 0 {
+					b.Declines++
+				}
+			}
+
+			if len(series) >= yearSessions+breadthWindow {
+				withYear++
+				high, low := newHighLow(series)
+				if high {
+					b.NewHighs++
+				}
+				if low {
+					b.NewLows++
+				}
+			}
+		}
+
+		if b.Members == 0 {
+			continue
+		}
+		if with50 > 0 {
+			v := float64(above50) / float64(with50)
+			b.AboveMA50 = &v
+		}
+		if with200 > 0 {
+			v := float64(above200) / float64(with200)
+			b.AboveMA200 = &v
+		}
+		if b.Declines > 0 {
+			v := float64(b.Advances) / float64(b.Declines)
+			b.AdvanceDecline = &v
+		}
+		if withYear > 0 {
+			v := float64(b.NewHighs-b.NewLows) / float64(withYear)
+			b.NetNewHighs = &v
+		}
+		breadth[sector] = b
+	}
+
+	return breadth
+}
+
+// CalculateBreadthScore calculates breadth score from the share of members
+// above their moving averages, net advances and net new highs.
+func CalculateBreadthScore(breadth map[string]SectorBreadth, sectors []string) map[string]float64 {
+	if len(breadth) == 0 {
+		return defaultScores(sectors)
+	}
+
+	above200 := make(map[string]float64)
+	above50 := make(map[string]float64)
+	netAdvances := make(map[string]float64)
+	netHighs := make(map[string]float64)
+	for sector, b := range breadth {
+		if b.AboveMA200 != nil {
+			above200[sector] = *b.AboveMA200
+		}
+		if b.AboveMA50 != nil {
+			above50[sector] = *b.AboveMA50
+		}
+		// The A/D ratio is unbounded, so net advances are scored instead
+		if total := b.Advances + b.Declines; total > 0 {
+			netAdvances[sector] = float64(b.Advances-b.Declines) / float64(total)
+		}
+		if b.NetNewHighs != nil {
+			netHighs[sector] = *b.NetNewHighs
+		}
+	}
+
+	// Combine with weights: 30% above 200-day MA, 25% above 50-day MA,
+	// 25% net advances, 20% net new highs, renormalized over the components
+	// a sector has
+	components := []struct {
+		scores map[string]float64
+		weight float64
+	}{
+		{NormalizeScoreZScore(above200, true), 0.30},
+		{NormalizeScoreZScore(above50, true), 0.25},
+		{NormalizeScoreZScore(netAdvances, true), 0.25},
+		{NormalizeScoreZScore(netHighs, true), 0.20},
+	}
+
+	breadthScores := make(map[string]float64)
+	for _, sector := range sectors {
+		var sum, weight float64
+		for _, c := range components {
+			if score, ok := c.scores[sector]; ok {
+				sum += c.weight * score
+				weight += c.weight
+			}
+		}
+		if weight == 0 {
+			breadthScores[sector] = 50.0
+			continue
+		}
+		breadthScores[sector] = math.Round(sum/weight*100) / 100
+	}
+
+	return breadthScores
+}
+
+// movingAverage returns the mean of the last n prices of series.
+func movingAverage(series data.PriceSeries, n int) float64 {
+	var sum float64
+	for _, bar := range series[len(series)-n:] {
+		sum += ReturnPrice(bar)
+	}
+	return sum / float64(n)
+}
+
+// newHighLow reports whether series closed at a 52-week high, or low, in any
+// of its last breadthWindow sessions.
+func newHighLow(series data.PriceSeries) (high, low bool) {
+	for i := len(series) - breadthWindow; i < len(series); i++ {
+		price := ReturnPrice(series[i])
+		maxPrev, minPrev := math.Inf(-1), math.Inf(1)
+		for _, bar := range series[i-yearSessions+1 : i] {
+			p := ReturnPrice(bar)
+			maxPrev = math.Max(maxPrev, p)
+			minPrev = math.Min(minPrev, p)
+		}
+		if price > maxPrev {
+			high = true
+		}
+		if price < minPrev {
+			low = true
+		}
+	}
+	return high, low
+}
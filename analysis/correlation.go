@@ -0,0 +1,295 @@
+// Sector return correlations and clustering.
+
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+
+	"sector-analyzer/calendar"
+	"sector-analyzer/data"
+)
+
+// SectorReturns holds daily returns aligned on the sessions every sector
+// traded: Returns[t][i] is the return of Sectors[i] on Dates[t].
+type SectorReturns struct {
+	Sectors []string
+	Dates   []time.Time
+	Returns [][]float64
+}
+
+// AlignedReturns computes daily returns of the given sectors over the
+// sessions they all have prices for. Sectors without prices are dropped.
+func AlignedReturns(prices data.SectorPrices, sectors []string) SectorReturns {
+	var included []string
+	byDate := make(map[time.Time][]float64)
+	for _, sector := range sectors {
+		series := prices[sector]
+		if len(series) < 2 {
+			continue
+		}
+		i := len(included)
+		included = append(included, sector)
+		for _, bar := range series {
+			day := calendar.NYSE.Date(bar.Date)
+			row, ok := byDate[day]
+			if !ok {
+				if i > 0 {
+					continue // a session an earlier sector lacks
+				}
+				row = make([]float64, 0, len(sectors))
+			}
+			if len(row) != i {
+				continue
+			}
+			byDate[day] = append(row, ReturnPrice(bar))
+		}
+	}
+
+	var dates []time.Time
+	for day, row := range byDate {
+		if len(row) == len(included) {
+			dates = append(dates, day)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	r := SectorReturns{Sectors: included}
+	for t := 1; t < len(dates); t++ {
+		prev, cur := byDate[dates[t-1]], byDate[dates[t]]
+		row := make([]float64, len(included))
+		valid := true
+		for i := range row {
+			if prev[i] <= 0 {
+				valid = false
+				break
+			}
+			row[i] = cur[i]/prev[i] - 1
+		}
+		if valid {
+			r.Dates = append(r.Dates, dates[t])
+			r.Returns = append(r.Returns, row)
+		}
+	}
+	return r
+}
+
+// Window returns the last n sessions of returns, or all of them if fewer.
+func (r SectorReturns) Window(n int) SectorReturns {
+	if n <= 0 || n >= len(r.Returns) {
+		return r
+	}
+	start := len(r.Returns) - n
+	return SectorReturns{Sectors: r.Sectors, Dates: r.Dates[start:], Returns: r.Returns[start:]}
+}
+
+// matrix returns the returns as a sessions x sectors matrix.
+func (r SectorReturns) matrix() *mat.Dense {
+	flat := make([]float64, 0, len(r.Returns)*len(r.Sectors))
+	for _, row := range r.Returns {
+		flat = append(flat, row...)
+	}
+	return mat.NewDense(len(r.Returns), len(r.Sectors), flat)
+}
+
+// Covariance returns the covariance matrix of daily returns, annualized
+// over 252 sessions. It is nil with fewer than two sessions.
+func (r SectorReturns) Covariance() [][]float64 {
+	if len(r.Returns) < 2 || len(r.Sectors) == 0 {
+		return nil
+	}
+	cov := mat.NewSymDense(len(r.Sectors), nil)
+	stat.CovarianceMatrix(cov, r.matrix(), nil)
+	cov.ScaleSym(252, cov)
+	return symRows(cov)
+}
+
+// Correlation returns the correlation matrix of daily returns. It is nil
+// with fewer than two sessions.
+func (r SectorReturns) Correlation() [][]float64 {
+	if len(r.Returns) < 2 || len(r.Sectors) == 0 {
+		return nil
+	}
+	corr := mat.NewSymDense(len(r.Sectors), nil)
+	stat.CorrelationMatrix(corr, r.matrix(), nil)
+	return symRows(corr)
+}
+
+func symRows(m *mat.SymDense) [][]float64 {
+	n := m.SymmetricDim()
+	rows := make([][]float64, n)
+	for i := range rows {
+		rows[i] = make([]float64, n)
+		for j := range rows[i] {
+			rows[i][j] = m.At(i, j)
+		}
+	}
+	return rows
+}
+
+// Volatilities returns the square roots of a covariance matrix's diagonal.
+func Volatilities(cov [][]float64) []float64 {
+	vols := make([]float64, len(cov))
+	for i := range cov {
+		vols[i] = math.Sqrt(cov[i][i])
+	}
+	return vols
+}
+
+// DiversificationRatio is the weighted average volatility of the holdings
+// over the volatility of the portfolio: 1 for perfectly correlated sectors,
+// rising as correlations fall. It is 0 when the portfolio has no variance.
+func DiversificationRatio(weights []float64, cov [][]float64) float64 {
+	vols := Volatilities(cov)
+	var weighted, variance float64
+	for i, wi := range weights {
+		weighted += wi * vols[i]
+		for j, wj := range weights {
+			variance += wi * wj * cov[i][j]
+		}
+	}
+	if variance <= 0 {
+		return 0
+	}
+	return weighted / math.Sqrt(variance)
+}
+
+// AverageCorrelation returns the mean pairwise correlation of the given
+// sector indices, or NaN for fewer than two.
+func AverageCorrelation(corr [][]float64, members []int) float64 {
+	var sum float64
+	var pairs int
+	for a := 0; a < len(members); a++ {
+		for b := a + 1; b < len(members); b++ {
+			sum += corr[members[a]][members[b]]
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return math.NaN()
+	}
+	return sum / float64(pairs)
+}
+
+// RollingAverageCorrelation returns the mean pairwise correlation over a
+// trailing window of sessions, sampled every step sessions and dated by the
+// window's last session.
+func RollingAverageCorrelation(r SectorReturns, window, step int) data.TimeSeries {
+	var ts data.TimeSeries
+	if window < 2 || step < 1 || len(r.Sectors) < 2 {
+		return ts
+	}
+	all := make([]int, len(r.Sectors))
+	for i := range all {
+		all[i] = i
+	}
+	// Anchor the samples on the latest session
+	for end := len(r.Returns); end >= window; end -= step {
+		sub := SectorReturns{Sectors: r.Sectors, Returns: r.Returns[end-window : end]}
+		ts.Dates = append(ts.Dates, r.Dates[end-1])
+		ts.Values = append(ts.Values, AverageCorrelation(sub.Correlation(), all))
+	}
+	for i, j := 0, len(ts.Dates)-1; i < j; i, j = i+1, j-1 {
+		ts.Dates[i], ts.Dates[j] = ts.Dates[j], ts.Dates[i]
+		ts.Values[i], ts.Values[j] = ts.Values[j], ts.Values[i]
+	}
+	return ts
+}
+
+// ClusterMerge is one step of hierarchical clustering: two clusters joined
+// at their average pairwise correlation. Distance is sqrt((1-ρ)/2), the
+// correlation distance, which is 0 for identical and 1 for opposite returns.
+type ClusterMerge struct {
+	Left        []string `json:"left"`
+	Right       []string `json:"right"`
+	Correlation float64  `json:"correlation"`
+	Distance    float64  `json:"distance"`
+}
+
+// ClusterSectors performs average-linkage agglomerative clustering on a
+// correlation matrix, repeatedly joining the two clusters with the highest
+// average pairwise correlation. The merges are in order, forming a
+// dendrogram with len(sectors)-1 steps.
+func ClusterSectors(sectors []string, corr [][]float64) []ClusterMerge {
+	clusters := make([][]int, len(sectors))
+	for i := range clusters {
+		clusters[i] = []int{i}
+	}
+
+	var merges []ClusterMerge
+	for len(clusters) > 1 {
+		bestA, bestB, best := 0, 1, math.Inf(-1)
+		for a := 0; a < len(clusters); a++ {
+			for b := a + 1; b < len(clusters); b++ {
+				var sum float64
+				for _, i := range clusters[a] {
+					for _, j := range clusters[b] {
+						sum += corr[i][j]
+					}
+				}
+				avg := sum / float64(len(clusters[a])*len(clusters[b]))
+				if avg > best {
+					bestA, bestB, best = a, b, avg
+				}
+			}
+		}
+
+		merges = append(merges, ClusterMerge{
+			Left:        sectorNames(sectors, clusters[bestA]),
+			Right:       sectorNames(sectors, clusters[bestB]),
+			Correlation: best,
+			Distance:    math.Sqrt(math.Max(0, (1-best)/2)),
+		})
+		clusters[bestA] = append(clusters[bestA], clusters[bestB]...)
+		clusters = append(clusters[:bestB], clusters[bestB+1:]...)
+	}
+	return merges
+}
+
+// CutClusters replays merges whose correlation is at least minCorrelation
+// and returns the resulting groups of sectors, largest first.
+func CutClusters(sectors []string, merges []ClusterMerge, minCorrelation float64) [][]string {
+	group := make(map[string]int, len(sectors))
+	for i, sector := range sectors {
+		group[sector] = i
+	}
+	for _, m := range merges {
+		if m.Correlation < minCorrelation {
+			break
+		}
+		from, to := group[m.Right[0]], group[m.Left[0]]
+		for sector, g := range group {
+			if g == from {
+				group[sector] = to
+			}
+		}
+	}
+
+	members := make(map[int][]string)
+	for _, sector := range sectors {
+		members[group[sector]] = append(members[group[sector]], sector)
+	}
+	var clusters [][]string
+	for _, m := range members {
+		clusters = append(clusters, m)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+func sectorNames(sectors []string, indices []int) []string {
+	names := make([]string, len(indices))
+	for i, idx := range indices {
+		names[i] = sectors[idx]
+	}
+	return names
+}
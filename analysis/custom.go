@@ -0,0 +1,305 @@
+// Custom signals defined by expressions over sector metrics.
+
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/stat"
+
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// metric is a raw per-sector value custom signal expressions can use, and
+// the data it is computed from.
+type metric struct {
+	inputs  []string
+	compute func(allData *data.AllData) map[string]float64
+}
+
+// metrics are the values available to expressions. Returns, relative
+// strength, volatility and growth rates are in percent.
+var metrics = map[string]metric{
+	"ret_3mo":          {[]string{"sector_prices"}, priceReturn("3mo")},
+	"ret_6mo":          {[]string{"sector_prices"}, priceReturn("6mo")},
+	"ret_12mo":         {[]string{"sector_prices"}, priceReturn("12mo")},
+	"rel_strength":     {[]string{"sector_prices"}, func(d *data.AllData) map[string]float64 { return CalculateRelativeStrength(d.SectorPrices, 12) }},
+	"vol_3mo":          {[]string{"sector_prices"}, volatility(63)},
+	"vol_12mo":         {[]string{"sector_prices"}, volatility(252)},
+	"volume_trend":     {[]string{"sector_prices"}, func(d *data.AllData) map[string]float64 { return CalculateVolumeTrend(d.SectorPrices, 20, 50) }},
+	"rsi":              {[]string{"sector_prices"}, indicator("rsi")},
+	"macd":             {[]string{"sector_prices"}, indicator("macd")},
+	"roc":              {[]string{"sector_prices"}, indicator("roc")},
+	"trend":            {[]string{"sector_prices"}, indicator("trend")},
+	"bollinger":        {[]string{"sector_prices"}, indicator("bollinger")},
+	"forward_pe":       {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.ForwardPE })},
+	"trailing_pe":      {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.TrailingPE })},
+	"price_to_book":    {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.PriceToBook })},
+	"price_to_sales":   {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.PriceToSales })},
+	"peg_ratio":        {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.PEGRatio })},
+	"earnings_growth":  {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.EarningsGrowth })},
+	"return_on_equity": {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.ReturnOnEquity })},
+	"net_margin":       {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.NetMargin })},
+	"dividend_yield":   {[]string{"sector_info"}, infoField(func(i data.SectorInfo) *float64 { return i.DividendYield })},
+	"equity_risk_premium": {[]string{"sector_info", "macro_data"}, func(d *data.AllData) map[string]float64 {
+		return CalculateEquityRiskPremium(d.SectorInfo, d.MacroData)
+	}},
+	"rate_sensitivity": {[]string{"sector_prices", "macro_data"}, func(d *data.AllData) map[string]float64 {
+		return CalculateRateSensitivity(d.SectorPrices, d.MacroData["treasury_10y"])
+	}},
+	"employment_growth": {[]string{"employment_data"}, func(d *data.AllData) map[string]float64 { return CalculateEmploymentGrowth(d.EmploymentData) }},
+	"rd_intensity":      {[]string{"rd_data"}, func(d *data.AllData) map[string]float64 { return d.RDData }},
+	"above_ma50":        {[]string{"holdings", "constituent_prices"}, breadthField(func(b SectorBreadth) *float64 { return b.AboveMA50 })},
+	"above_ma200":       {[]string{"holdings", "constituent_prices"}, breadthField(func(b SectorBreadth) *float64 { return b.AboveMA200 })},
+	"advance_decline":   {[]string{"holdings", "constituent_prices"}, breadthField(func(b SectorBreadth) *float64 { return b.AdvanceDecline })},
+	"net_new_highs":     {[]string{"holdings", "constituent_prices"}, breadthField(func(b SectorBreadth) *float64 { return b.NetNewHighs })},
+}
+
+// MetricNames returns the names of the metrics expressions can use, sorted.
+func MetricNames() []string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpressionSignal is a signal defined by an expression over metrics (see
+// config.SignalDefinition). A sector missing any metric the expression
+// reads, or whose value is not finite, is left out and scores 50.
+type ExpressionSignal struct {
+	name        string
+	description string
+	expression  *Expression
+	normalize   string
+}
+
+// NewExpressionSignal parses a signal definition, checking that its
+// expression is well formed and reads only known metrics.
+func NewExpressionSignal(def config.SignalDefinition) (*ExpressionSignal, error) {
+	expr, err := ParseExpression(def.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("signal %q: %w", def.Name, err)
+	}
+	for _, name := range expr.Variables() {
+		if _, ok := metrics[name]; !ok {
+			return nil, fmt.Errorf("signal %q: unknown metric %q (available: %s)",
+				def.Name, name, strings.Join(MetricNames(), ", "))
+		}
+	}
+	if !slices.Contains(config.SignalNormalizations, def.Normalize) {
+		return nil, fmt.Errorf("signal %q: normalize must be one of: %s",
+			def.Name, strings.Join(config.SignalNormalizations, ", "))
+	}
+	return &ExpressionSignal{
+		name:        def.Name,
+		description: def.Description,
+		expression:  expr,
+		normalize:   def.Normalize,
+	}, nil
+}
+
+// RegisterExpressionSignals parses and registers every definition. Nothing
+// is registered if any definition is invalid.
+func RegisterExpressionSignals(defs []config.SignalDefinition) error {
+	var signals []*ExpressionSignal
+	var errs []error
+	for _, def := range defs {
+		sig, err := NewExpressionSignal(def)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		signals = append(signals, sig)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	for _, sig := range signals {
+		if err := RegisterSignal(sig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ExpressionSignal) Name() string { return e.name }
+
+// Description returns the signal's description from its definition.
+func (e *ExpressionSignal) Description() string { return e.description }
+
+// Expression returns the expression's source.
+func (e *ExpressionSignal) Expression() string { return e.expression.String() }
+
+// Normalize returns how raw values are scaled to scores, one of
+// config.SignalNormalizations.
+func (e *ExpressionSignal) Normalize() string { return e.normalize }
+
+// Inputs returns the data read by the expression's metrics.
+func (e *ExpressionSignal) Inputs() []string {
+	var inputs []string
+	for _, name := range e.expression.Variables() {
+		for _, input := range metrics[name].inputs {
+			if !slices.Contains(inputs, input) {
+				inputs = append(inputs, input)
+			}
+		}
+	}
+	return inputs
+}
+
+// Compute evaluates the expression for every sector with all its metrics
+// and normalizes the results.
+func (e *ExpressionSignal) Compute(allData *data.AllData) map[string]float64 {
+	values := make(map[string]map[string]float64)
+	for _, name := range e.expression.Variables() {
+		values[name] = metrics[name].compute(allData)
+	}
+
+	raw := make(map[string]float64)
+	for _, sector := range universeSectors(allData) {
+		vars := make(map[string]float64, len(values))
+		complete := true
+		for name, bySector := range values {
+			v, ok := bySector[sector]
+			if !ok || math.IsNaN(v) {
+				complete = false
+				break
+			}
+			vars[name] = v
+		}
+		if !complete {
+			continue
+		}
+		if v := e.expression.Eval(vars); !math.IsNaN(v) && !math.IsInf(v, 0) {
+			raw[sector] = v
+		}
+	}
+
+	switch e.normalize {
+	case "minmax":
+		return NormalizeScore(raw, true)
+	case "rank":
+		return NormalizeScoreRank(raw)
+	case "none":
+		scores := make(map[string]float64, len(raw))
+		for sector, v := range raw {
+			scores[sector] = math.Round(math.Max(0, math.Min(100, v))*100) / 100
+		}
+		return scores
+	default:
+		return NormalizeScoreZScore(raw, true)
+	}
+}
+
+// NormalizeScoreRank scores values by percentile rank, 0 for the lowest and
+// 100 for the highest; tied values share their average rank.
+func NormalizeScoreRank(values map[string]float64) map[string]float64 {
+	if len(values) < 2 {
+		return defaultScores(mapKeys(values))
+	}
+
+	keys := mapKeys(values)
+	sort.Slice(keys, func(i, j int) bool { return values[keys[i]] < values[keys[j]] })
+
+	scores := make(map[string]float64, len(keys))
+	for i := 0; i < len(keys); {
+		j := i
+		for j+1 < len(keys) && values[keys[j+1]] == values[keys[i]] {
+			j++
+		}
+		rank := float64(i+j) / 2
+		for k := i; k <= j; k++ {
+			scores[keys[k]] = math.Round(rank/float64(len(keys)-1)*100*100) / 100
+		}
+		i = j + 1
+	}
+	return scores
+}
+
+func mapKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// priceReturn returns the metric of each sector's return over a period of
+// CalculatePriceReturns.
+func priceReturn(period string) func(*data.AllData) map[string]float64 {
+	return func(d *data.AllData) map[string]float64 {
+		values := make(map[string]float64)
+		for sector, returns := range CalculatePriceReturns(d.SectorPrices) {
+			if r, ok := returns[period]; ok {
+				values[sector] = r
+			}
+		}
+		return values
+	}
+}
+
+// volatility returns the metric of each sector's annualized standard
+// deviation of daily returns over the last sessions sessions.
+func volatility(sessions int) func(*data.AllData) map[string]float64 {
+	return func(d *data.AllData) map[string]float64 {
+		values := make(map[string]float64)
+		for sector, series := range d.SectorPrices {
+			if sector == "_benchmark" || len(series) <= sessions {
+				continue
+			}
+			var returns []float64
+			for i := len(series) - sessions; i < len(series); i++ {
+				if prev := ReturnPrice(series[i-1]); prev > 0 {
+					returns = append(returns, ReturnPrice(series[i])/prev-1)
+				}
+			}
+			if len(returns) > 1 {
+				values[sector] = stat.StdDev(returns, nil) * math.Sqrt(252) * 100
+			}
+		}
+		return values
+	}
+}
+
+// indicator returns the metric of a momentum indicator's latest value.
+func indicator(name string) func(*data.AllData) map[string]float64 {
+	return func(d *data.AllData) map[string]float64 {
+		return CalculateIndicatorSignals(d.SectorPrices, []string{name})[name]
+	}
+}
+
+// infoField returns the metric of a sector info field.
+func infoField(field func(data.SectorInfo) *float64) func(*data.AllData) map[string]float64 {
+	return func(d *data.AllData) map[string]float64 {
+		values := make(map[string]float64)
+		for sector, info := range d.SectorInfo {
+			if v := field(info); v != nil {
+				values[sector] = *v
+			}
+		}
+		return values
+	}
+}
+
+// breadthField returns the metric of a constituent breadth measure.
+func breadthField(field func(SectorBreadth) *float64) func(*data.AllData) map[string]float64 {
+	return func(d *data.AllData) map[string]float64 {
+		values := make(map[string]float64)
+		for sector, b := range CalculateBreadth(d.Holdings, d.ConstituentPrices) {
+			if v := field(b); v != nil {
+				values[sector] = *v
+			}
+		}
+		return values
+	}
+}
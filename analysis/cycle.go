@@ -0,0 +1,278 @@
+// The business-cycle sector rotation model.
+
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// CycleIndicators are the macro readings that place a month in a phase.
+// YieldCurve is the 10y-2y Treasury spread; Activity is industrial
+// production's growth over a year, a proxy for the ISM manufacturing index,
+// and ActivityChange its change over three months; UnemploymentGap is the
+// three-month average unemployment rate over its low of the prior year (the
+// Sahm rule); Inflation is CPI over a year and InflationChange its change
+// over six months. All are in percent or percentage points, and nil when
+// the series don't cover the month.
+type CycleIndicators struct {
+	YieldCurve      *float64 `json:"yield_curve"`
+	Activity        *float64 `json:"activity"`
+	ActivityChange  *float64 `json:"activity_change"`
+	UnemploymentGap *float64 `json:"unemployment_gap"`
+	Inflation       *float64 `json:"inflation"`
+	InflationChange *float64 `json:"inflation_change"`
+}
+
+// CycleState is the current business-cycle phase and how sectors have done
+// in it. Month is the latest month with activity and unemployment data.
+// PhaseMonths counts the months of history in each phase. Performance is
+// each sector's average return over the benchmark config.CycleReleaseLag
+// months after a month in Phase; Source is "history" when it is scored from Performance
+// and "textbook" when Phase has fewer than config.CycleMinMonths months.
+type CycleState struct {
+	Phase       string             `json:"phase"`
+	Month       string             `json:"month"`
+	Indicators  CycleIndicators    `json:"indicators"`
+	Source      string             `json:"source"`
+	PhaseMonths map[string]int     `json:"phase_months"`
+	Performance map[string]float64 `json:"performance,omitempty"`
+}
+
+// ClassifyPhase maps macro readings to a phase of config.CyclePhases, or
+// "" when neither activity nor unemployment is known:
+//   - recession: unemployment has risen by the Sahm gap while activity is not
+//     yet recovering, or activity is contracting and slowing further
+//   - early: activity is accelerating from below-trend growth
+//   - late: activity is slowing with a flat curve or rising inflation
+//   - mid: otherwise, an expansion at or above trend
+func ClassifyPhase(ind CycleIndicators) string {
+	if ind.Activity == nil && ind.UnemploymentGap == nil {
+		return ""
+	}
+	accelerating := ind.ActivityChange != nil && *ind.ActivityChange > 0
+	decelerating := ind.ActivityChange != nil && *ind.ActivityChange < 0
+	sahm := ind.UnemploymentGap != nil && *ind.UnemploymentGap >= config.CycleSahmGap
+	contracting := ind.Activity != nil && *ind.Activity < 0
+	belowTrend := ind.Activity != nil && *ind.Activity < config.CycleTrendGrowth
+	flatCurve := ind.YieldCurve != nil && *ind.YieldCurve < config.CycleFlatCurve
+	risingInflation := ind.InflationChange != nil && *ind.InflationChange > 0
+
+	switch {
+	case (sahm && !accelerating) || (contracting && decelerating):
+		return "recession"
+	case accelerating && (belowTrend || sahm):
+		return "early"
+	case decelerating && (flatCurve || risingInflation):
+		return "late"
+	default:
+		return "mid"
+	}
+}
+
+// cycleSeries holds the monthly macro series behind CycleIndicators.
+type cycleSeries struct {
+	tenYear, twoYear, production, unemployment, cpi map[time.Time]float64
+}
+
+func newCycleSeries(macro data.MacroData) cycleSeries {
+	return cycleSeries{
+		tenYear:      MonthEnds(macro["treasury_10y"]),
+		twoYear:      MonthEnds(macro["treasury_2y"]),
+		production:   MonthEnds(macro["industrial_production"]),
+		unemployment: MonthEnds(macro["unemployment"]),
+		cpi:          MonthEnds(macro["cpi"]),
+	}
+}
+
+// indicators returns the readings for month.
+func (c cycleSeries) indicators(month time.Time) CycleIndicators {
+	var ind CycleIndicators
+	if long, ok := c.tenYear[month]; ok {
+		if short, ok := c.twoYear[month]; ok {
+			ind.YieldCurve = floatPtr(long - short)
+		}
+	}
+	if growth, ok := yearOverYear(c.production, month); ok {
+		ind.Activity = floatPtr(growth)
+		if prev, ok := yearOverYear(c.production, month.AddDate(0, -3, 0)); ok {
+			ind.ActivityChange = floatPtr(growth - prev)
+		}
+	}
+	if inflation, ok := yearOverYear(c.cpi, month); ok {
+		ind.Inflation = floatPtr(inflation)
+		if prev, ok := yearOverYear(c.cpi, month.AddDate(0, -6, 0)); ok {
+			ind.InflationChange = floatPtr(inflation - prev)
+		}
+	}
+	if current, ok := threeMonthAverage(c.unemployment, month); ok {
+		low := math.Inf(1)
+		for i := 1; i <= 12; i++ {
+			if avg, ok := threeMonthAverage(c.unemployment, month.AddDate(0, -i, 0)); ok {
+				low = math.Min(low, avg)
+			}
+		}
+		if !math.IsInf(low, 1) {
+			ind.UnemploymentGap = floatPtr(current - low)
+		}
+	}
+	return ind
+}
+
+// months returns the months with activity and unemployment data, in order.
+// With only one of the two series, its months are used.
+func (c cycleSeries) months() []time.Time {
+	var months []time.Time
+	for month := range c.production {
+		if _, ok := c.unemployment[month]; ok || len(c.unemployment) == 0 {
+			months = append(months, month)
+		}
+	}
+	if len(c.production) == 0 {
+		for month := range c.unemployment {
+			months = append(months, month)
+		}
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+	return months
+}
+
+// AnalyzeCycle classifies every month of the macro history, places the
+// latest month in its phase and measures how each sector did, relative to
+// the benchmark, config.CycleReleaseLag months after each month of that
+// phase. A month's data is published partway through the next, so the
+// lag keeps returns earned before publication out of the measure.
+//
+// Performance is measured over the history in prices and macro, the
+// five-year window every refresh fetches (and as_of truncates), not over
+// everything in the store: older stored bars exist only for tickers that have
+// been refreshed for years, so mixing them in would make the lookback differ
+// by sector and by installation. Five years usually covers two or three
+// phases; the textbook fallback handles the rest.
+func AnalyzeCycle(prices data.SectorPrices, macro data.MacroData, sectors []string) CycleState {
+	series := newCycleSeries(macro)
+	months := series.months()
+	state := CycleState{PhaseMonths: make(map[string]int), Source: "textbook"}
+
+	phases := make(map[time.Time]string, len(months))
+	for _, month := range months {
+		if phase := ClassifyPhase(series.indicators(month)); phase != "" {
+			phases[month] = phase
+			state.PhaseMonths[phase]++
+		}
+	}
+	for i := len(months) - 1; i >= 0; i-- {
+		if phase, ok := phases[months[i]]; ok {
+			state.Phase = phase
+			state.Month = months[i].Format("2006-01")
+			state.Indicators = series.indicators(months[i])
+			break
+		}
+	}
+	if state.Phase == "" {
+		return state
+	}
+
+	benchmark := MonthlyReturns(prices["_benchmark"])
+	returns := make(map[string]map[time.Time]float64, len(sectors))
+	for _, sector := range sectors {
+		returns[sector] = MonthlyReturns(prices[sector])
+	}
+	excess := make(map[string][]float64)
+	for month, phase := range phases {
+		if phase != state.Phase {
+			continue
+		}
+		traded := month.AddDate(0, config.CycleReleaseLag, 0)
+		market, ok := benchmark[traded]
+		if !ok {
+			continue
+		}
+		for _, sector := range sectors {
+			if r, ok := returns[sector][traded]; ok {
+				excess[sector] = append(excess[sector], r-market)
+			}
+		}
+	}
+
+	performance := make(map[string]float64)
+	for sector, values := range excess {
+		if len(values) >= config.CycleMinMonths {
+			var sum float64
+			for _, v := range values {
+				sum += v
+			}
+			performance[sector] = sum / float64(len(values))
+		}
+	}
+	if len(performance) > 1 {
+		state.Performance = performance
+		state.Source = "history"
+	}
+	return state
+}
+
+// CalculateCycleScore scores sectors by their performance in the current
+// phase or, without enough history, by the textbook rotation: 75 for the
+// phase's favored sectors, 25 for its unfavored ones and 50 otherwise.
+func CalculateCycleScore(state CycleState, sectors []string) map[string]float64 {
+	if state.Phase == "" {
+		return defaultScores(sectors)
+	}
+
+	var scores map[string]float64
+	if state.Source == "history" {
+		scores = NormalizeScoreZScore(state.Performance, true)
+	} else {
+		scores = make(map[string]float64)
+		tilt := config.CycleTextbook[state.Phase]
+		for _, sector := range tilt.Favored {
+			scores[sector] = 75.0
+		}
+		for _, sector := range tilt.Unfavored {
+			scores[sector] = 25.0
+		}
+	}
+
+	cycleScores := make(map[string]float64, len(sectors))
+	for _, sector := range sectors {
+		cycleScores[sector] = getOrDefault(scores, sector, 50.0)
+	}
+	return cycleScores
+}
+
+// yearOverYear returns the percent change of a monthly series over the year
+// to month.
+func yearOverYear(values map[time.Time]float64, month time.Time) (float64, bool) {
+	current, ok := values[month]
+	if !ok {
+		return 0, false
+	}
+	prev, ok := values[month.AddDate(-1, 0, 0)]
+	if !ok || prev <= 0 {
+		return 0, false
+	}
+	return (current/prev - 1) * 100, true
+}
+
+// threeMonthAverage returns the average of a monthly series over the three
+// months to month.
+func threeMonthAverage(values map[time.Time]float64, month time.Time) (float64, bool) {
+	var sum float64
+	for i := 0; i < 3; i++ {
+		v, ok := values[month.AddDate(0, -i, 0)]
+		if !ok {
+			return 0, false
+		}
+		sum += v
+	}
+	return sum / 3, true
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
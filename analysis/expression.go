@@ -0,0 +1,321 @@
+// A small arithmetic expression language over named sector metrics.
+
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed arithmetic expression. It supports numbers,
+// variables, + - * / and ^ with the usual precedence, unary minus,
+// parentheses and the functions abs, sqrt, log, min and max.
+type Expression struct {
+	source    string
+	variables []string
+	eval      func(vars map[string]float64) float64
+}
+
+// expressionFuncs maps function names to their implementations and arity;
+// an arity of -1 accepts two or more arguments.
+var expressionFuncs = map[string]struct {
+	arity int
+	fn    func(args []float64) float64
+}{
+	"abs":  {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt": {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"log":  {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"min": {-1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	}},
+	"max": {-1, func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	}},
+}
+
+// ParseExpression parses source, reporting the column of the first error.
+func ParseExpression(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, seen: make(map[string]bool)}
+	eval, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("column %d: unexpected %q", t.pos, t.text)
+	}
+	return &Expression{source: source, variables: p.variables, eval: eval}, nil
+}
+
+// String returns the expression's source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Variables returns the variables the expression reads, in order of first use.
+func (e *Expression) Variables() []string {
+	return e.variables
+}
+
+// Eval evaluates the expression. Every variable must be in vars. Division by
+// zero and functions outside their domain give infinite or NaN results.
+func (e *Expression) Eval(vars map[string]float64) float64 {
+	return e.eval(vars)
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int
+}
+
+// tokenize splits source into numbers, identifiers and the operators
+// + - * / ^ ( ) and commas. Positions are 1-based columns.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// Exponent, as in 1e-3
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for i = j; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+					}
+				}
+			}
+			text := string(runes[start:i])
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %d: invalid number %q", start+1, text)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: v, pos: start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start + 1})
+		case strings.ContainsRune("+-*/^(),", r):
+			tokens = append(tokens, token{kind: tokenOp, text: string(r), pos: i + 1})
+			i++
+		default:
+			return nil, fmt.Errorf("column %d: unexpected character %q", i+1, r)
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, text: "end of expression", pos: len(runes) + 1}), nil
+}
+
+// exprParser is a recursive descent parser building a closure per node.
+type exprParser struct {
+	tokens    []token
+	i         int
+	variables []string
+	seen      map[string]bool
+}
+
+type evalFunc = func(vars map[string]float64) float64
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEnd {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("column %d: expected %q, found %q", t.pos, op, t.text)
+	}
+	return nil
+}
+
+// sum := product (("+" | "-") product)*
+func (p *exprParser) sum() (evalFunc, error) {
+	return p.binaryChain(p.product, "+", "-")
+}
+
+// product := unary (("*" | "/") unary)*
+func (p *exprParser) product() (evalFunc, error) {
+	return p.binaryChain(p.unary, "*", "/")
+}
+
+// binaryChain parses operands joined left to right by any of ops.
+func (p *exprParser) binaryChain(operand func() (evalFunc, error), ops ...string) (evalFunc, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenOp || !slices.Contains(ops, t.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binary(t.text, left, right)
+	}
+}
+
+func binary(op string, l, r evalFunc) evalFunc {
+	switch op {
+	case "+":
+		return func(v map[string]float64) float64 { return l(v) + r(v) }
+	case "-":
+		return func(v map[string]float64) float64 { return l(v) - r(v) }
+	case "*":
+		return func(v map[string]float64) float64 { return l(v) * r(v) }
+	default:
+		return func(v map[string]float64) float64 { return l(v) / r(v) }
+	}
+}
+
+// unary := ("-" | "+") unary | power
+func (p *exprParser) unary() (evalFunc, error) {
+	if p.accept("-") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v map[string]float64) float64 { return -operand(v) }, nil
+	}
+	if p.accept("+") {
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power := primary ("^" unary)?, so 2^-1 and -2^2 (= -4) parse as usual
+func (p *exprParser) power() (evalFunc, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("^") {
+		return base, nil
+	}
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(v map[string]float64) float64 { return math.Pow(base(v), exponent(v)) }, nil
+}
+
+// primary := number | variable | function "(" sum ("," sum)* ")" | "(" sum ")"
+func (p *exprParser) primary() (evalFunc, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenNumber:
+		value := t.value
+		return func(map[string]float64) float64 { return value }, nil
+
+	case t.kind == tokenIdent && p.accept("("):
+		f, ok := expressionFuncs[t.text]
+		if !ok {
+			return nil, fmt.Errorf("column %d: unknown function %q", t.pos, t.text)
+		}
+		var args []evalFunc
+		if !p.accept(")") {
+			for {
+				arg, err := p.sum()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if !p.accept(",") {
+					break
+				}
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if (f.arity > 0 && len(args) != f.arity) || (f.arity < 0 && len(args) < 2) {
+			want := "1 argument"
+			if f.arity < 0 {
+				want = "at least 2 arguments"
+			}
+			return nil, fmt.Errorf("column %d: %s takes %s, got %d", t.pos, t.text, want, len(args))
+		}
+		return func(v map[string]float64) float64 {
+			values := make([]float64, len(args))
+			for i, arg := range args {
+				values[i] = arg(v)
+			}
+			return f.fn(values)
+		}, nil
+
+	case t.kind == tokenIdent:
+		name := t.text
+		if !p.seen[name] {
+			p.seen[name] = true
+			p.variables = append(p.variables, name)
+		}
+		return func(v map[string]float64) float64 { return v[name] }, nil
+
+	case t.kind == tokenOp && t.text == "(":
+		inner, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+	return nil, fmt.Errorf("column %d: unexpected %q", t.pos, t.text)
+}
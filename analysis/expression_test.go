@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+func TestExpressionEval(t *testing.T) {
+	tests := []struct {
+		source string
+		vars   map[string]float64
+		want   float64
+	}{
+		{"1 + 2 * 3", nil, 7},
+		{"(1 + 2) * 3", nil, 9},
+		{"10 - 4 - 3", nil, 3},
+		{"12 / 3 / 2", nil, 2},
+		{"2 ^ 3 ^ 2", nil, 512},
+		{"-2 ^ 2", nil, -4},
+		{"2 ^ -1", nil, 0.5},
+		{"--3", nil, 3},
+		{"1e-3 * 1000", nil, 1},
+		{"1.5e2", nil, 150},
+		{"abs(-3) + sqrt(16)", nil, 7},
+		{"min(3, 1, 2) + max(3, 1, 2)", nil, 4},
+		{"x / y", map[string]float64{"x": 6, "y": 4}, 1.5},
+		{"-x ^ 2 + y", map[string]float64{"x": 3, "y": 1}, -8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expr, err := ParseExpression(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Eval(tt.vars); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Eval = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"abs(1, 2)", "abs takes 1 argument, got 2"},
+		{"sqrt()", "sqrt takes 1 argument, got 0"},
+		{"min(1)", "min takes at least 2 arguments, got 1"},
+		{"max(1, )", `unexpected ")"`},
+		{"median(1, 2)", `unknown function "median"`},
+		{"1 +", "column"},
+		{"(1 + 2", `expected ")"`},
+		{"1 2", `unexpected "2"`},
+		{"2 $ 3", "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := ParseExpression(tt.source)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewExpressionSignalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  config.SignalDefinition
+		want string
+	}{
+		{"unknown metric", config.SignalDefinition{Name: "x", Expression: "earnings_yeild * 2"}, `unknown metric "earnings_yeild"`},
+		{"parse error", config.SignalDefinition{Name: "x", Expression: "abs(forward_pe, 1)"}, "abs takes 1 argument"},
+		{"bad normalization", config.SignalDefinition{Name: "x", Expression: "forward_pe", Normalize: "log"}, "normalize must be one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewExpressionSignal(tt.def)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionSignalDivisionByZero(t *testing.T) {
+	signal, err := NewExpressionSignal(config.SignalDefinition{
+		Name:       "earnings_yield",
+		Expression: "1 / forward_pe",
+		Normalize:  "rank",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pe := func(v float64) data.SectorInfo { return data.SectorInfo{ForwardPE: &v} }
+	allData := &data.AllData{
+		Sectors: []string{"A", "B", "C"},
+		SectorInfo: map[string]data.SectorInfo{
+			"A": pe(10),
+			"B": pe(20),
+			"C": pe(0),
+		},
+	}
+
+	scores := signal.Compute(allData)
+	if _, ok := scores["C"]; ok {
+		t.Errorf("sector with a zero P/E was scored: %v", scores)
+	}
+	if scores["A"] != 100 || scores["B"] != 0 {
+		t.Errorf("scores = %v, want A 100 and B 0", scores)
+	}
+	if got := (SectorScore{Components: scores}).Component("C"); got != 50 {
+		t.Errorf("missing component = %g, want 50", got)
+	}
+}
+
+func TestNormalizeScoreRank(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]float64
+		want   map[string]float64
+	}{
+		{"distinct", map[string]float64{"a": 3, "b": 1, "c": 2}, map[string]float64{"a": 100, "b": 0, "c": 50}},
+		{"ties share their average rank", map[string]float64{"a": 1, "b": 2, "c": 2, "d": 3}, map[string]float64{"a": 0, "b": 50, "c": 50, "d": 100}},
+		{"tied lowest", map[string]float64{"a": 1, "b": 1, "c": 5, "d": 9}, map[string]float64{"a": 16.67, "b": 16.67, "c": 66.67, "d": 100}},
+		{"all equal", map[string]float64{"a": 4, "b": 4, "c": 4}, map[string]float64{"a": 50, "b": 50, "c": 50}},
+		{"single value", map[string]float64{"a": 7}, map[string]float64{"a": 50}},
+		{"empty", map[string]float64{}, map[string]float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeScoreRank(tt.values)
+			if len(got) != len(tt.want) {
+				t.Fatalf("NormalizeScoreRank = %v, want %v", got, tt.want)
+			}
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("NormalizeScoreRank = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
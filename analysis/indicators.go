@@ -0,0 +1,339 @@
+// Technical indicators for price series.
+
+package analysis
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+
+	"sector-analyzer/data"
+)
+
+// Indicators return one value per bar, aligned with the input series. Bars
+// before an indicator has enough history are NaN. Prices are those used for
+// returns (see ReturnPrice), so indicators follow RETURN_BASIS.
+
+// closes returns the price of each bar.
+func closes(series data.PriceSeries) []float64 {
+	values := make([]float64, len(series))
+	for i, bar := range series {
+		values[i] = ReturnPrice(bar)
+	}
+	return values
+}
+
+// nanSlice returns n NaN values.
+func nanSlice(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// SMA calculates the simple moving average of closes over period bars.
+func SMA(series data.PriceSeries, period int) []float64 {
+	return sma(closes(series), period)
+}
+
+func sma(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 {
+		return out
+	}
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA calculates the exponential moving average of closes over period bars,
+// seeded with the simple average of the first period bars.
+func EMA(series data.PriceSeries, period int) []float64 {
+	return ema(closes(series), period)
+}
+
+// ema skips leading NaN values, so it can smooth another indicator.
+func ema(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if period <= 0 {
+		return out
+	}
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < period {
+		return out
+	}
+
+	alpha := 2 / float64(period+1)
+	var seed float64
+	for _, v := range values[start : start+period] {
+		seed += v
+	}
+	prev := seed / float64(period)
+	out[start+period-1] = prev
+	for i := start + period; i < len(values); i++ {
+		prev = alpha*values[i] + (1-alpha)*prev
+		out[i] = prev
+	}
+	return out
+}
+
+// RSI calculates Wilder's relative strength index (0-100) over period bars.
+func RSI(series data.PriceSeries, period int) []float64 {
+	values := closes(series)
+	out := nanSlice(len(values))
+	if period <= 0 || len(values) <= period {
+		return out
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	out[period] = rsiValue(gain, loss)
+
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		up, down := 0.0, 0.0
+		if change > 0 {
+			up = change
+		} else {
+			down = -change
+		}
+		gain = (gain*float64(period-1) + up) / float64(period)
+		loss = (loss*float64(period-1) + down) / float64(period)
+		out[i] = rsiValue(gain, loss)
+	}
+	return out
+}
+
+func rsiValue(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// MACDResult holds the MACD line (fast EMA less slow EMA), its signal line
+// and their difference.
+type MACDResult struct {
+	MACD      []float64
+	Signal    []float64
+	Histogram []float64
+}
+
+// MACD calculates moving average convergence/divergence, conventionally
+// with fast=12, slow=26 and signal=9.
+func MACD(series data.PriceSeries, fast, slow, signal int) MACDResult {
+	values := closes(series)
+	fastEMA := ema(values, fast)
+	slowEMA := ema(values, slow)
+
+	line := nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(fastEMA[i]) && !math.IsNaN(slowEMA[i]) {
+			line[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+	signalLine := ema(line, signal)
+	histogram := nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(line[i]) && !math.IsNaN(signalLine[i]) {
+			histogram[i] = line[i] - signalLine[i]
+		}
+	}
+	return MACDResult{MACD: line, Signal: signalLine, Histogram: histogram}
+}
+
+// BollingerBands holds a moving average and the bands k standard deviations
+// above and below it.
+type BollingerBands struct {
+	Middle []float64
+	Upper  []float64
+	Lower  []float64
+}
+
+// Bollinger calculates Bollinger bands over period bars, conventionally with
+// period=20 and k=2. The standard deviation is that of the population.
+func Bollinger(series data.PriceSeries, period int, k float64) BollingerBands {
+	values := closes(series)
+	middle := sma(values, period)
+	upper := nanSlice(len(values))
+	lower := nanSlice(len(values))
+	for i := period - 1; i < len(values) && period > 0; i++ {
+		var ss float64
+		for _, v := range values[i-period+1 : i+1] {
+			ss += (v - middle[i]) * (v - middle[i])
+		}
+		sd := math.Sqrt(ss / float64(period))
+		upper[i] = middle[i] + k*sd
+		lower[i] = middle[i] - k*sd
+	}
+	return BollingerBands{Middle: middle, Upper: upper, Lower: lower}
+}
+
+// ATR calculates Wilder's average true range over period bars. Highs and
+// lows are scaled by the same adjustment as the close.
+func ATR(series data.PriceSeries, period int) []float64 {
+	out := nanSlice(len(series))
+	if period <= 0 || len(series) <= period {
+		return out
+	}
+
+	trueRange := make([]float64, len(series))
+	for i, bar := range series {
+		scale := 1.0
+		if bar.Close > 0 {
+			scale = ReturnPrice(bar) / bar.Close
+		}
+		high, low := bar.High*scale, bar.Low*scale
+		if high == 0 || low == 0 {
+			high, low = ReturnPrice(bar), ReturnPrice(bar)
+		}
+		tr := high - low
+		if i > 0 {
+			prev := ReturnPrice(series[i-1])
+			tr = math.Max(tr, math.Max(math.Abs(high-prev), math.Abs(low-prev)))
+		}
+		trueRange[i] = tr
+	}
+
+	var sum float64
+	for _, tr := range trueRange[1 : period+1] {
+		sum += tr
+	}
+	prev := sum / float64(period)
+	out[period] = prev
+	for i := period + 1; i < len(series); i++ {
+		prev = (prev*float64(period-1) + trueRange[i]) / float64(period)
+		out[i] = prev
+	}
+	return out
+}
+
+// ROC calculates the rate of change over period bars, in percent.
+func ROC(series data.PriceSeries, period int) []float64 {
+	values := closes(series)
+	out := nanSlice(len(values))
+	for i := period; i < len(values) && period > 0; i++ {
+		if values[i-period] > 0 {
+			out[i] = (values[i]/values[i-period] - 1) * 100
+		}
+	}
+	return out
+}
+
+// TrendSlope calculates the least-squares slope of log price over period
+// bars, annualized over 252 sessions: 0.10 is a trend of about 10% a year.
+func TrendSlope(series data.PriceSeries, period int) []float64 {
+	values := closes(series)
+	out := nanSlice(len(values))
+	if period < 2 {
+		return out
+	}
+
+	x := make([]float64, period)
+	for i := range x {
+		x[i] = float64(i)
+	}
+	y := make([]float64, period)
+	for i := period - 1; i < len(values); i++ {
+		valid := true
+		for j, v := range values[i-period+1 : i+1] {
+			if v <= 0 {
+				valid = false
+				break
+			}
+			y[j] = math.Log(v)
+		}
+		if !valid {
+			continue
+		}
+		_, slope := stat.LinearRegression(x, y, nil, false)
+		out[i] = slope * 252
+	}
+	return out
+}
+
+// lastValue returns the final value of an indicator, or false if it is NaN
+// or the indicator is empty.
+func lastValue(values []float64) (float64, bool) {
+	if len(values) == 0 || math.IsNaN(values[len(values)-1]) {
+		return 0, false
+	}
+	return values[len(values)-1], true
+}
+
+// indicatorSignal returns a sector's latest value of a momentum sub-signal
+// (one of config.MomentumIndicatorNames). Higher values are stronger:
+//   - rsi: 14-session RSI
+//   - macd: 12/26/9 MACD histogram as a fraction of price
+//   - roc: 63-session (3-month) rate of change
+//   - trend: 126-session (6-month) trend slope
+//   - bollinger: %B, the close's position within 20-session 2σ bands
+func indicatorSignal(series data.PriceSeries, name string) (float64, bool) {
+	switch name {
+	case "rsi":
+		return lastValue(RSI(series, 14))
+	case "macd":
+		hist, ok := lastValue(MACD(series, 12, 26, 9).Histogram)
+		price := ReturnPrice(series[len(series)-1])
+		if !ok || price <= 0 {
+			return 0, false
+		}
+		return hist / price, true
+	case "roc":
+		return lastValue(ROC(series, 63))
+	case "trend":
+		return lastValue(TrendSlope(series, 126))
+	case "bollinger":
+		bands := Bollinger(series, 20, 2)
+		upper, ok := lastValue(bands.Upper)
+		lower, _ := lastValue(bands.Lower)
+		if !ok || upper == lower {
+			return 0, false
+		}
+		return (ReturnPrice(series[len(series)-1]) - lower) / (upper - lower), true
+	}
+	return 0, false
+}
+
+// CalculateIndicatorSignals returns each selected indicator's latest value
+// per sector, keyed by indicator name.
+func CalculateIndicatorSignals(prices data.SectorPrices, indicators []string) map[string]map[string]float64 {
+	signals := make(map[string]map[string]float64, len(indicators))
+	for _, name := range indicators {
+		values := make(map[string]float64)
+		for sector, series := range prices {
+			if sector == "_benchmark" || len(series) == 0 {
+				continue
+			}
+			if v, ok := indicatorSignal(series, name); ok {
+				values[sector] = v
+			}
+		}
+		signals[name] = values
+	}
+	return signals
+}
@@ -10,34 +10,49 @@ import (
 	"sector-analyzer/data"
 )
 
-// SectorScore contains a sector's complete scoring breakdown.
+// SectorScore contains a sector's complete scoring breakdown. Components
+// holds its score on every registered signal, by name. CycleScore is only
+// computed for the cycle and blend models and is 50 otherwise.
 type SectorScore struct {
-	Sector           string   `json:"sector"`
-	OpportunityScore float64  `json:"opportunity_score"`
-	Rank             int      `json:"rank"`
-	MomentumScore    float64  `json:"momentum_score"`
-	ValuationScore   float64  `json:"valuation_score"`
-	GrowthScore      float64  `json:"growth_score"`
-	InnovationScore  float64  `json:"innovation_score"`
-	MacroScore       float64  `json:"macro_score"`
-	PriceReturn3Mo   *float64 `json:"price_return_3mo"`
-	PriceReturn6Mo   *float64 `json:"price_return_6mo"`
-	PriceReturn12Mo  *float64 `json:"price_return_12mo"`
-	RelativeStrength *float64 `json:"relative_strength"`
-	ForwardPE        *float64 `json:"forward_pe"`
-	EmploymentGrowth *float64 `json:"employment_growth"`
-	RDIntensity      *float64 `json:"rd_intensity"`
+	Sector            string             `json:"sector"`
+	OpportunityScore  float64            `json:"opportunity_score"`
+	Rank              int                `json:"rank"`
+	Components        map[string]float64 `json:"components"`
+	FactorScore       float64            `json:"factor_score"`
+	CycleScore        float64            `json:"cycle_score"`
+	PriceReturn3Mo    *float64           `json:"price_return_3mo"`
+	PriceReturn6Mo    *float64           `json:"price_return_6mo"`
+	PriceReturn12Mo   *float64           `json:"price_return_12mo"`
+	RelativeStrength  *float64           `json:"relative_strength"`
+	ForwardPE         *float64           `json:"forward_pe"`
+	EquityRiskPremium *float64           `json:"equity_risk_premium"`
+	EmploymentGrowth  *float64           `json:"employment_growth"`
+	RDIntensity       *float64           `json:"rd_intensity"`
 }
 
 // SectorScorer calculates opportunity scores for all sectors.
+// ValuationMethod is one of config.ValuationMethods, FundamentalsSource one
+// of config.FundamentalsSources, MomentumIndicators a subset of
+// config.MomentumIndicatorNames and Model one of config.ScoringModels.
 type SectorScorer struct {
-	Weights map[string]float64
+	Weights            map[string]float64
+	ValuationMethod    string
+	FundamentalsSource string
+	MomentumIndicators []string
+	Model              string
 }
 
-// NewSectorScorer creates a new scorer with optional custom weights.
+// Component returns the sector's score on a signal, or 50 if the signal
+// was not computed.
+func (s SectorScore) Component(name string) float64 {
+	return getOrDefault(s.Components, name, 50.0)
+}
+
+// NewSectorScorer creates a new scorer with optional custom weights, keyed
+// by signal name.
 func NewSectorScorer(weights map[string]float64) *SectorScorer {
 	if weights == nil {
-		weights = config.DefaultWeights
+		weights = DefaultWeights()
 	}
 
 	// Normalize weights to sum to 1.0
@@ -51,49 +66,69 @@ func NewSectorScorer(weights map[string]float64) *SectorScorer {
 		}
 	}
 
-	return &SectorScorer{Weights: weights}
+	return &SectorScorer{
+		Weights:            weights,
+		ValuationMethod:    config.ValuationMethod,
+		FundamentalsSource: config.FundamentalsSource,
+		MomentumIndicators: config.MomentumIndicators,
+		Model:              config.ScoringModel,
+	}
 }
 
 // CalculateScores computes opportunity scores for all sectors.
 func (s *SectorScorer) CalculateScores(allData *data.AllData) []SectorScore {
+	scores, _ := s.CalculateScoresWithCycle(allData)
+	return scores
+}
+
+// CalculateScoresWithCycle computes opportunity scores and, for the cycle
+// and blend models, the business-cycle state behind the cycle scores. The
+// state is nil for the factor model, which doesn't analyze the cycle.
+func (s *SectorScorer) CalculateScoresWithCycle(allData *data.AllData) ([]SectorScore, *CycleState) {
+	sectors := universeSectors(allData)
+	scored := s.fundamentals(allData)
+
 	// Calculate component scores
-	momentumScores := CalculateMomentumScore(allData.SectorPrices)
-	valuationScores := CalculateValuationScore(nil, allData.SectorInfo)
-	growthScores := CalculateGrowthScore(allData.EmploymentData)
-	innovationScores := CalculateInnovationScore(allData.RDData)
-	macroScores := CalculateMacroScore(allData.SectorPrices, allData.MacroData)
+	signals := s.Signals()
+	components := make([]map[string]float64, len(signals))
+	for i, sig := range signals {
+		components[i] = sig.Compute(scored)
+	}
+
+	// Only the cycle and blend models analyze the business cycle
+	var cycle *CycleState
+	cycleScores := defaultScores(sectors)
+	if s.Model == "cycle" || s.Model == "blend" {
+		state := AnalyzeCycle(allData.SectorPrices, allData.MacroData, sectors)
+		cycle = &state
+		cycleScores = CalculateCycleScore(state, sectors)
+	}
 
 	// Calculate raw metrics for display
 	priceReturns := CalculatePriceReturns(allData.SectorPrices)
 	relStrength := CalculateRelativeStrength(allData.SectorPrices, 12)
+	premiums := CalculateEquityRiskPremium(scored.SectorInfo, allData.MacroData)
 	employmentGrowth := CalculateEmploymentGrowth(allData.EmploymentData)
 
 	// Build sector scores
 	var scores []SectorScore
 
-	for _, sector := range config.SectorNames {
-		momentum := getOrDefault(momentumScores, sector, 50.0)
-		valuation := getOrDefault(valuationScores, sector, 50.0)
-		growth := getOrDefault(growthScores, sector, 50.0)
-		innovation := getOrDefault(innovationScores, sector, 50.0)
-		macro := getOrDefault(macroScores, sector, 50.0)
+	for _, sector := range sectors {
+		score := SectorScore{
+			Sector:     sector,
+			Components: make(map[string]float64, len(signals)),
+			CycleScore: getOrDefault(cycleScores, sector, 50.0),
+		}
 
 		// Calculate weighted opportunity score
-		opportunity := (s.Weights["momentum"] * momentum) +
-			(s.Weights["valuation"] * valuation) +
-			(s.Weights["growth"] * growth) +
-			(s.Weights["innovation"] * innovation) +
-			(s.Weights["macro"] * macro)
-
-		score := SectorScore{
-			Sector:           sector,
-			OpportunityScore: math.Round(opportunity*100) / 100,
-			MomentumScore:    momentum,
-			ValuationScore:   valuation,
-			GrowthScore:      growth,
-			InnovationScore:  innovation,
-			MacroScore:       macro,
+		var opportunity float64
+		for i, sig := range signals {
+			value := getOrDefault(components[i], sector, 50.0)
+			score.Components[sig.Name()] = value
+			opportunity += s.Weights[sig.Name()] * value
 		}
+		score.FactorScore = math.Round(opportunity*100) / 100
+		score.OpportunityScore = s.opportunity(score.FactorScore, score.CycleScore)
 
 		// Add raw metrics
 		if returns, ok := priceReturns[sector]; ok {
@@ -112,15 +147,19 @@ func (s *SectorScorer) CalculateScores(allData *data.AllData) []SectorScore {
 			score.RelativeStrength = &rs
 		}
 
-		if info, ok := allData.SectorInfo[sector]; ok && info.ForwardPE != nil {
+		if info, ok := scored.SectorInfo[sector]; ok && info.ForwardPE != nil {
 			score.ForwardPE = info.ForwardPE
 		}
 
+		if erp, ok := premiums[sector]; ok {
+			score.EquityRiskPremium = &erp
+		}
+
 		if eg, ok := employmentGrowth[sector]; ok {
 			score.EmploymentGrowth = &eg
 		}
 
-		if rd, ok := allData.RDData[sector]; ok {
+		if rd, ok := scored.RDData[sector]; ok {
 			score.RDIntensity = &rd
 		}
 
@@ -136,7 +175,16 @@ func (s *SectorScorer) CalculateScores(allData *data.AllData) []SectorScore {
 		scores[i].Rank = i + 1
 	}
 
-	return scores
+	return scores, cycle
+}
+
+// universeSectors returns the sectors the data was fetched for, falling back
+// to the default universe for data built without one.
+func universeSectors(allData *data.AllData) []string {
+	if len(allData.Sectors) > 0 {
+		return allData.Sectors
+	}
+	return config.SectorNames
 }
 
 // SummaryReport contains summary statistics and insights.
@@ -208,20 +256,14 @@ func (s *SectorScorer) GetSummaryReport(scores []SectorScore) SummaryReport {
 	// Identify top sector drivers
 	var drivers []string
 	topSector := scores[0]
-	if topSector.MomentumScore >= 70 {
-		drivers = append(drivers, "strong momentum")
-	}
-	if topSector.ValuationScore >= 70 {
-		drivers = append(drivers, "attractive valuation")
-	}
-	if topSector.GrowthScore >= 70 {
-		drivers = append(drivers, "employment growth")
-	}
-	if topSector.InnovationScore >= 70 {
-		drivers = append(drivers, "high R&D investment")
-	}
-	if topSector.MacroScore >= 70 {
-		drivers = append(drivers, "favorable macro positioning")
+	for _, name := range SignalNames() {
+		if topSector.Component(name) >= 70 {
+			driver, ok := signalDrivers[name]
+			if !ok {
+				driver = "strong " + name
+			}
+			drivers = append(drivers, driver)
+		}
 	}
 
 	return SummaryReport{
@@ -239,6 +281,73 @@ func (s *SectorScorer) GetSummaryReport(scores []SectorScore) SummaryReport {
 	}
 }
 
+// Signals returns the registered signals configured with the scorer's
+// settings.
+func (s *SectorScorer) Signals() []Signal {
+	opts := SignalOptions{
+		ValuationMethod:    s.ValuationMethod,
+		MomentumIndicators: s.MomentumIndicators,
+	}
+	signals := Signals()
+	for i, sig := range signals {
+		if c, ok := sig.(ConfigurableSignal); ok {
+			signals[i] = c.Configure(opts)
+		}
+	}
+	return signals
+}
+
+// opportunity combines the factor model's weighted score and the cycle
+// model's score according to the scoring model.
+func (s *SectorScorer) opportunity(factor, cycle float64) float64 {
+	switch s.Model {
+	case "cycle":
+		return cycle
+	case "blend":
+		return math.Round((factor+cycle)/2*100) / 100
+	default:
+		return factor
+	}
+}
+
+// fundamentals returns the data signals are computed on. With the
+// "holdings" source, look-through forward P/E, earnings growth and R&D
+// intensity replace the ETF and Damodaran figures wherever they are
+// available; allData itself is not modified.
+func (s *SectorScorer) fundamentals(allData *data.AllData) *data.AllData {
+	if s.FundamentalsSource != "holdings" || len(allData.LookThrough) == 0 {
+		return allData
+	}
+
+	sectorInfo := make(map[string]data.SectorInfo, len(allData.SectorInfo))
+	for sector, info := range allData.SectorInfo {
+		sectorInfo[sector] = info
+	}
+	rdData := make(data.RDData, len(allData.RDData))
+	for sector, rd := range allData.RDData {
+		rdData[sector] = rd
+	}
+
+	for sector, lt := range allData.LookThrough {
+		info := sectorInfo[sector]
+		if lt.ForwardPE != nil {
+			info.ForwardPE = lt.ForwardPE
+		}
+		if lt.EarningsGrowth != nil {
+			info.EarningsGrowth = lt.EarningsGrowth
+		}
+		sectorInfo[sector] = info
+		if lt.RDIntensity != nil {
+			rdData[sector] = *lt.RDIntensity
+		}
+	}
+
+	scored := *allData
+	scored.SectorInfo = sectorInfo
+	scored.RDData = rdData
+	return &scored
+}
+
 // RunAnalysis is a convenience function to run full analysis.
 func RunAnalysis(allData *data.AllData, weights map[string]float64) ([]SectorScore, SummaryReport) {
 	scorer := NewSectorScorer(weights)
@@ -0,0 +1,174 @@
+// The registry of signals behind opportunity scores.
+
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// Signal is a component of the opportunity score. Its name is the key of
+// its weight and of its score in responses ("<name>_score"). Inputs lists
+// the data it reads, by AllData's JSON field names. Compute returns a score
+// from 0 to 100 per sector; sectors it leaves out score a neutral 50.
+type Signal interface {
+	Name() string
+	Inputs() []string
+	Compute(allData *data.AllData) map[string]float64
+}
+
+// SignalOptions are the scorer settings that change how a signal computes.
+type SignalOptions struct {
+	ValuationMethod    string
+	MomentumIndicators []string
+}
+
+// ConfigurableSignal is a Signal that depends on the scorer's settings. The
+// scorer computes the Signal Configure returns rather than the registered one.
+type ConfigurableSignal interface {
+	Signal
+	Configure(opts SignalOptions) Signal
+}
+
+var (
+	signalsMu sync.RWMutex
+	signals   = []Signal{
+		builtinSignal{name: "momentum", inputs: []string{"sector_prices"}, compute: momentumSignal},
+		builtinSignal{name: "valuation", inputs: []string{"sector_info", "macro_data"}, compute: valuationSignal},
+		builtinSignal{name: "growth", inputs: []string{"employment_data"}, compute: growthSignal},
+		builtinSignal{name: "innovation", inputs: []string{"rd_data"}, compute: innovationSignal},
+		builtinSignal{name: "quality", inputs: []string{"sector_info"}, compute: qualitySignal},
+		builtinSignal{name: "breadth", inputs: []string{"holdings", "constituent_prices"}, compute: breadthSignal},
+		builtinSignal{name: "macro", inputs: []string{"sector_prices", "macro_data"}, compute: macroSignal},
+	}
+)
+
+// signalDrivers describes a top sector scoring 70 or more on a signal.
+var signalDrivers = map[string]string{
+	"momentum":   "strong momentum",
+	"valuation":  "attractive valuation",
+	"growth":     "employment growth",
+	"innovation": "high R&D investment",
+	"quality":    "profitable, growing holdings",
+	"breadth":    "broad-based participation",
+	"macro":      "favorable macro positioning",
+}
+
+// RegisterSignal adds a signal to those every scorer computes. Its default
+// weight is config.DefaultWeights[name], or zero if it has none, so it is
+// reported but moves no score until weighted. Names must be unique and not
+// collide with the scores every response carries.
+func RegisterSignal(sig Signal) error {
+	name := sig.Name()
+	switch name {
+	case "", "opportunity", "factor", "cycle":
+		return fmt.Errorf("invalid signal name %q", name)
+	}
+
+	signalsMu.Lock()
+	defer signalsMu.Unlock()
+	for _, existing := range signals {
+		if existing.Name() == name {
+			return fmt.Errorf("signal %q is already registered", name)
+		}
+	}
+	signals = append(signals, sig)
+	return nil
+}
+
+// Signals returns the registered signals in registration order, the
+// built-in ones first.
+func Signals() []Signal {
+	signalsMu.RLock()
+	defer signalsMu.RUnlock()
+	return append([]Signal(nil), signals...)
+}
+
+// SignalNames returns the names of the registered signals in order.
+func SignalNames() []string {
+	var names []string
+	for _, sig := range Signals() {
+		names = append(names, sig.Name())
+	}
+	return names
+}
+
+// DefaultWeights returns the default weight of every registered signal.
+func DefaultWeights() map[string]float64 {
+	weights := make(map[string]float64)
+	for _, name := range SignalNames() {
+		weights[name] = config.DefaultWeights[name]
+	}
+	return weights
+}
+
+// builtinSignal is a signal computed by one of this package's functions.
+type builtinSignal struct {
+	name    string
+	inputs  []string
+	opts    SignalOptions
+	compute func(allData *data.AllData, opts SignalOptions) map[string]float64
+}
+
+func (b builtinSignal) Name() string     { return b.name }
+func (b builtinSignal) Inputs() []string { return b.inputs }
+
+func (b builtinSignal) Compute(allData *data.AllData) map[string]float64 {
+	return b.compute(allData, b.opts)
+}
+
+func (b builtinSignal) Configure(opts SignalOptions) Signal {
+	b.opts = opts
+	return b
+}
+
+func momentumSignal(allData *data.AllData, opts SignalOptions) map[string]float64 {
+	return CalculateMomentumScoreWithIndicators(allData.SectorPrices, universeSectors(allData), opts.MomentumIndicators)
+}
+
+func breadthSignal(allData *data.AllData, _ SignalOptions) map[string]float64 {
+	breadth := CalculateBreadth(allData.Holdings, allData.ConstituentPrices)
+	return CalculateBreadthScore(breadth, universeSectors(allData))
+}
+
+// valuationSignal applies the valuation method: relative P/E, equity risk
+// premium, or an even blend of the two.
+func valuationSignal(allData *data.AllData, opts SignalOptions) map[string]float64 {
+	sectors := universeSectors(allData)
+	premiums := CalculateEquityRiskPremium(allData.SectorInfo, allData.MacroData)
+	switch opts.ValuationMethod {
+	case "erp":
+		return CalculateERPScore(premiums, sectors)
+	case "blend":
+		relative := CalculateValuationScore(nil, allData.SectorInfo, sectors)
+		erp := CalculateERPScore(premiums, sectors)
+		blended := make(map[string]float64, len(sectors))
+		for _, sector := range sectors {
+			v := (getOrDefault(relative, sector, 50.0) + getOrDefault(erp, sector, 50.0)) / 2
+			blended[sector] = math.Round(v*100) / 100
+		}
+		return blended
+	default:
+		return CalculateValuationScore(nil, allData.SectorInfo, sectors)
+	}
+}
+
+func growthSignal(allData *data.AllData, _ SignalOptions) map[string]float64 {
+	return CalculateGrowthScore(allData.EmploymentData, universeSectors(allData))
+}
+
+func innovationSignal(allData *data.AllData, _ SignalOptions) map[string]float64 {
+	return CalculateInnovationScore(allData.RDData, universeSectors(allData))
+}
+
+func qualitySignal(allData *data.AllData, _ SignalOptions) map[string]float64 {
+	return CalculateQualityScore(allData.SectorInfo, universeSectors(allData))
+}
+
+func macroSignal(allData *data.AllData, _ SignalOptions) map[string]float64 {
+	return CalculateMacroScore(allData.SectorPrices, allData.MacroData, universeSectors(allData))
+}
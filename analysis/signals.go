@@ -3,9 +3,12 @@ package analysis
 
 import (
 	"math"
+	"sort"
+	"time"
 
 	"gonum.org/v1/gonum/stat"
 
+	"sector-analyzer/calendar"
 	"sector-analyzer/config"
 	"sector-analyzer/data"
 )
@@ -110,10 +113,9 @@ func CalculatePriceReturns(prices data.SectorPrices) map[string]map[string]float
 
 		sectorReturns := make(map[string]float64)
 		for _, months := range config.MomentumPeriods {
-			tradingDays := months * 21
-			if len(series) >= tradingDays {
-				startPrice := series[len(series)-tradingDays].Close
-				endPrice := series[len(series)-1].Close
+			if start := windowStart(series, months); start >= 0 {
+				startPrice := ReturnPrice(series[start])
+				endPrice := ReturnPrice(series[len(series)-1])
 				ret := ((endPrice - startPrice) / startPrice) * 100
 				sectorReturns[periodKey(months)] = ret
 			}
@@ -147,19 +149,23 @@ func CalculateRelativeStrength(prices data.SectorPrices, periodMonths int) map[s
 		return map[string]float64{}
 	}
 
-	tradingDays := periodMonths * 21
-	if len(benchmarkSeries) < tradingDays {
+	benchmarkStart := windowStart(benchmarkSeries, periodMonths)
+	if benchmarkStart < 0 {
 		return map[string]float64{}
 	}
 
-	benchmarkReturn := (benchmarkSeries[len(benchmarkSeries)-1].Close/benchmarkSeries[len(benchmarkSeries)-tradingDays].Close - 1) * 100
+	benchmarkReturn := (ReturnPrice(benchmarkSeries[len(benchmarkSeries)-1])/ReturnPrice(benchmarkSeries[benchmarkStart]) - 1) * 100
 
 	relStrength := make(map[string]float64)
 	for sector, series := range prices {
-		if sector == "_benchmark" || len(series) < tradingDays {
+		if sector == "_benchmark" {
 			continue
 		}
-		sectorReturn := (series[len(series)-1].Close/series[len(series)-tradingDays].Close - 1) * 100
+		start := windowStart(series, periodMonths)
+		if start < 0 {
+			continue
+		}
+		sectorReturn := (ReturnPrice(series[len(series)-1])/ReturnPrice(series[start]) - 1) * 100
 		relStrength[sector] = sectorReturn - benchmarkReturn
 	}
 
@@ -199,7 +205,16 @@ func CalculateVolumeTrend(prices data.SectorPrices, shortPeriod, longPeriod int)
 }
 
 // CalculateMomentumScore calculates combined momentum score.
-func CalculateMomentumScore(prices data.SectorPrices) map[string]float64 {
+func CalculateMomentumScore(prices data.SectorPrices, sectors []string) map[string]float64 {
+	return CalculateMomentumScoreWithIndicators(prices, sectors, nil)
+}
+
+// CalculateMomentumScoreWithIndicators calculates momentum score with the
+// selected technical indicators (config.MomentumIndicatorNames) as further
+// sub-signals. They share config.IndicatorWeight of the score equally; the
+// returns, relative strength and volume components keep their proportions
+// in the rest.
+func CalculateMomentumScoreWithIndicators(prices data.SectorPrices, sectors []string, indicators []string) map[string]float64 {
 	returns := CalculatePriceReturns(prices)
 	relStrength := CalculateRelativeStrength(prices, 12)
 	volumeTrend := CalculateVolumeTrend(prices, 20, 50)
@@ -217,14 +232,26 @@ func CalculateMomentumScore(prices data.SectorPrices) map[string]float64 {
 	normRelStrength := NormalizeScoreZScore(relStrength, true)
 	normVolume := NormalizeScoreZScore(volumeTrend, true)
 
+	var normIndicators []map[string]float64
+	for _, values := range CalculateIndicatorSignals(prices, indicators) {
+		normIndicators = append(normIndicators, NormalizeScoreZScore(values, true))
+	}
+	baseWeight := 1.0
+	if len(normIndicators) > 0 {
+		baseWeight = 1 - config.IndicatorWeight
+	}
+
 	// Combine with weights: 50% returns, 35% relative strength, 15% volume
 	momentumScores := make(map[string]float64)
-	for _, sector := range config.SectorNames {
+	for _, sector := range sectors {
 		retScore := getOrDefault(normReturns, sector, 50.0)
 		rsScore := getOrDefault(normRelStrength, sector, 50.0)
 		volScore := getOrDefault(normVolume, sector, 50.0)
 
-		combined := (0.50 * retScore) + (0.35 * rsScore) + (0.15 * volScore)
+		combined := baseWeight * ((0.50 * retScore) + (0.35 * rsScore) + (0.15 * volScore))
+		for _, norm := range normIndicators {
+			combined += config.IndicatorWeight / float64(len(normIndicators)) * getOrDefault(norm, sector, 50.0)
+		}
 		momentumScores[sector] = math.Round(combined*100) / 100
 	}
 
@@ -232,7 +259,7 @@ func CalculateMomentumScore(prices data.SectorPrices) map[string]float64 {
 }
 
 // CalculateValuationScore calculates valuation score based on P/E ratios.
-func CalculateValuationScore(sectorPE map[string]float64, sectorInfo map[string]data.SectorInfo) map[string]float64 {
+func CalculateValuationScore(sectorPE map[string]float64, sectorInfo map[string]data.SectorInfo, sectors []string) map[string]float64 {
 	// Build P/E map from available sources
 	peMap := make(map[string]float64)
 
@@ -251,14 +278,14 @@ func CalculateValuationScore(sectorPE map[string]float64, sectorInfo map[string]
 	}
 
 	if len(peMap) == 0 {
-		return defaultScores()
+		return defaultScores(sectors)
 	}
 
 	// Lower P/E = better value = higher score
 	scores := NormalizeScoreZScore(peMap, false)
 
 	// Fill missing sectors
-	for _, sector := range config.SectorNames {
+	for _, sector := range sectors {
 		if _, exists := scores[sector]; !exists {
 			scores[sector] = 50.0
 		}
@@ -267,6 +294,47 @@ func CalculateValuationScore(sectorPE map[string]float64, sectorInfo map[string]
 	return scores
 }
 
+// CalculateEquityRiskPremium calculates each sector's earnings yield (the
+// inverse of forward P/E) less the latest 10-year Treasury yield.
+func CalculateEquityRiskPremium(sectorInfo map[string]data.SectorInfo, macroData data.MacroData) map[string]float64 {
+	treasury, ok := macroData["treasury_10y"]
+	if !ok || len(treasury.Values) == 0 {
+		return map[string]float64{}
+	}
+	// FRED quotes yields in percent
+	riskFree := treasury.Values[len(treasury.Values)-1] / 100
+
+	premiums := make(map[string]float64)
+	for sector, info := range sectorInfo {
+		if info.ForwardPE != nil && *info.ForwardPE > 0 {
+			premiums[sector] = 1 / *info.ForwardPE - riskFree
+		}
+	}
+	return premiums
+}
+
+// CalculateERPScore scores equity risk premiums against config.ERPAnchor
+// rather than against each other, so every sector scores lower when Treasury
+// yields rise relative to earnings.
+func CalculateERPScore(premiums map[string]float64, sectors []string) map[string]float64 {
+	if len(premiums) == 0 {
+		return defaultScores(sectors)
+	}
+
+	scores := make(map[string]float64)
+	for _, sector := range sectors {
+		erp, ok := premiums[sector]
+		if !ok {
+			scores[sector] = 50.0
+			continue
+		}
+		score := 50 + 50*(erp-config.ERPAnchor)/config.ERPScale
+		score = math.Max(0, math.Min(100, score))
+		scores[sector] = math.Round(score*100) / 100
+	}
+	return scores
+}
+
 // CalculateEmploymentGrowth calculates YoY employment growth by sector.
 func CalculateEmploymentGrowth(employment data.EmploymentData) map[string]float64 {
 	growthRates := make(map[string]float64)
@@ -289,17 +357,17 @@ func CalculateEmploymentGrowth(employment data.EmploymentData) map[string]float6
 }
 
 // CalculateGrowthScore calculates growth score based on employment trends.
-func CalculateGrowthScore(employment data.EmploymentData) map[string]float64 {
+func CalculateGrowthScore(employment data.EmploymentData, sectors []string) map[string]float64 {
 	growth := CalculateEmploymentGrowth(employment)
 
 	if len(growth) == 0 {
-		return defaultScores()
+		return defaultScores(sectors)
 	}
 
 	scores := NormalizeScoreZScore(growth, true)
 
 	// Fill missing sectors
-	for _, sector := range config.SectorNames {
+	for _, sector := range sectors {
 		if _, exists := scores[sector]; !exists {
 			scores[sector] = 50.0
 		}
@@ -309,9 +377,9 @@ func CalculateGrowthScore(employment data.EmploymentData) map[string]float64 {
 }
 
 // CalculateInnovationScore calculates innovation score based on R&D intensity.
-func CalculateInnovationScore(rdData data.RDData) map[string]float64 {
+func CalculateInnovationScore(rdData data.RDData, sectors []string) map[string]float64 {
 	if len(rdData) == 0 {
-		return defaultScores()
+		return defaultScores(sectors)
 	}
 
 	// Filter out zeros
@@ -323,13 +391,13 @@ func CalculateInnovationScore(rdData data.RDData) map[string]float64 {
 	}
 
 	if len(validRD) == 0 {
-		return defaultScores()
+		return defaultScores(sectors)
 	}
 
 	scores := NormalizeScoreZScore(validRD, true)
 
 	// Fill missing sectors with below-average score
-	for _, sector := range config.SectorNames {
+	for _, sector := range sectors {
 		if _, exists := scores[sector]; !exists {
 			scores[sector] = 30.0
 		}
@@ -338,6 +406,58 @@ func CalculateInnovationScore(rdData data.RDData) map[string]float64 {
 	return scores
 }
 
+// CalculateQualityScore calculates quality score from the profitability and
+// earnings growth of each sector ETF's holdings.
+func CalculateQualityScore(sectorInfo map[string]data.SectorInfo, sectors []string) map[string]float64 {
+	roe := make(map[string]float64)
+	margin := make(map[string]float64)
+	growth := make(map[string]float64)
+	for sector, info := range sectorInfo {
+		if info.ReturnOnEquity != nil {
+			roe[sector] = *info.ReturnOnEquity
+		}
+		if info.NetMargin != nil {
+			margin[sector] = *info.NetMargin
+		}
+		if info.EarningsGrowth != nil {
+			growth[sector] = *info.EarningsGrowth
+		}
+	}
+
+	if len(roe) == 0 && len(margin) == 0 && len(growth) == 0 {
+		return defaultScores(sectors)
+	}
+
+	// Combine with weights: 40% ROE, 30% net margin, 30% earnings growth,
+	// renormalized over the components a sector has
+	components := []struct {
+		scores map[string]float64
+		weight float64
+	}{
+		{NormalizeScoreZScore(roe, true), 0.40},
+		{NormalizeScoreZScore(margin, true), 0.30},
+		{NormalizeScoreZScore(growth, true), 0.30},
+	}
+
+	qualityScores := make(map[string]float64)
+	for _, sector := range sectors {
+		var sum, weight float64
+		for _, c := range components {
+			if score, ok := c.scores[sector]; ok {
+				sum += c.weight * score
+				weight += c.weight
+			}
+		}
+		if weight == 0 {
+			qualityScores[sector] = 50.0
+			continue
+		}
+		qualityScores[sector] = math.Round(sum/weight*100) / 100
+	}
+
+	return qualityScores
+}
+
 // CalculateRateSensitivity calculates sector sensitivity to interest rate changes.
 func CalculateRateSensitivity(prices data.SectorPrices, interestRates data.TimeSeries) map[string]float64 {
 	if len(interestRates.Values) == 0 {
@@ -350,7 +470,7 @@ func CalculateRateSensitivity(prices data.SectorPrices, interestRates data.TimeS
 	rateChanges := monthlyChanges(interestRates)
 
 	for sector, series := range prices {
-		if sector == "_benchmark" || len(series) < 252 {
+		if sector == "_benchmark" || windowStart(series, 12) < 0 {
 			continue
 		}
 
@@ -397,43 +517,40 @@ func monthlyChanges(ts data.TimeSeries) []float64 {
 	return changes
 }
 
-// monthlyReturnsFromPrices calculates monthly returns from daily prices.
+// monthlyReturnsFromPrices returns MonthlyReturns in month order.
 func monthlyReturnsFromPrices(series data.PriceSeries) []float64 {
-	if len(series) < 21 {
-		return nil
+	byMonth := MonthlyReturns(series)
+	months := make([]time.Time, 0, len(byMonth))
+	for month := range byMonth {
+		months = append(months, month)
 	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
 
-	var returns []float64
-	// Approximate monthly by taking every 21 trading days
-	for i := 21; i < len(series); i += 21 {
-		prevPrice := series[i-21].Close
-		currPrice := series[i].Close
-		if prevPrice > 0 {
-			ret := (currPrice - prevPrice) / prevPrice
-			returns = append(returns, ret)
-		}
+	returns := make([]float64, len(months))
+	for i, month := range months {
+		returns[i] = byMonth[month]
 	}
 	return returns
 }
 
 // CalculateMacroScore calculates macro sensitivity score.
-func CalculateMacroScore(prices data.SectorPrices, macroData data.MacroData) map[string]float64 {
+func CalculateMacroScore(prices data.SectorPrices, macroData data.MacroData, sectors []string) map[string]float64 {
 	interestRates, ok := macroData["treasury_10y"]
 	if !ok {
-		return defaultScores()
+		return defaultScores(sectors)
 	}
 
 	sensitivity := CalculateRateSensitivity(prices, interestRates)
 
 	if len(sensitivity) == 0 {
-		return defaultScores()
+		return defaultScores(sectors)
 	}
 
 	// Lower correlation with rates = more resilient = higher score
 	scores := NormalizeScoreZScore(sensitivity, false)
 
 	// Fill missing sectors
-	for _, sector := range config.SectorNames {
+	for _, sector := range sectors {
 		if _, exists := scores[sector]; !exists {
 			scores[sector] = 50.0
 		}
@@ -444,6 +561,84 @@ func CalculateMacroScore(prices data.SectorPrices, macroData data.MacroData) map
 
 // Helper functions
 
+// barCalendar dates price bars for every universe. Signals only see a
+// series, not the exchange it trades on, but the data package dates each
+// bar at noon UTC on its session's local date, so the UTC date is the
+// session date on every exchange. Its plain weekday arithmetic treats a
+// month ending on a holiday as in progress until the next month's first bar.
+var barCalendar = calendar.Weekdays
+
+// windowStart returns the index of the last bar on or before the weekday
+// months before the series' final bar, or -1 when the series does not reach
+// back that far.
+func windowStart(series data.PriceSeries, months int) int {
+	if len(series) == 0 {
+		return -1
+	}
+
+	target := barCalendar.LastTradingDay(series[len(series)-1].Date.AddDate(0, -months, 0))
+	return sort.Search(len(series), func(i int) bool {
+		return barCalendar.Date(series[i].Date).After(target)
+	}) - 1
+}
+
+// MonthKey returns the first day of t's month, as midnight UTC. Macro
+// observations are dated at midnight UTC; price bars are dated by
+// barCalendar first.
+func MonthKey(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthlyReturns returns a price series' return in each complete month,
+// from month-end to month-end, keyed by MonthKey. Months whose previous
+// month has no bars are left out.
+func MonthlyReturns(series data.PriceSeries) map[time.Time]float64 {
+	closes := make(map[time.Time]float64)
+	for i, bar := range series {
+		day := barCalendar.Date(bar.Date)
+		// Skip a month still in progress
+		if i == len(series)-1 && !day.Equal(barCalendar.LastTradingDay(endOfMonth(bar.Date))) {
+			break
+		}
+		closes[MonthKey(day)] = ReturnPrice(bar)
+	}
+
+	returns := make(map[time.Time]float64)
+	for month, v := range closes {
+		if prev, ok := closes[month.AddDate(0, -1, 0)]; ok && prev > 0 {
+			returns[month] = v/prev - 1
+		}
+	}
+	return returns
+}
+
+// MonthEnds returns the last observation of a series in each month, keyed
+// by MonthKey.
+func MonthEnds(ts data.TimeSeries) map[time.Time]float64 {
+	values := make(map[time.Time]float64)
+	for i, date := range ts.Dates {
+		if !math.IsNaN(ts.Values[i]) {
+			values[MonthKey(date)] = ts.Values[i]
+		}
+	}
+	return values
+}
+
+// endOfMonth returns the last day of the month of a bar dated t.
+func endOfMonth(t time.Time) time.Time {
+	d := barCalendar.Date(t)
+	return d.AddDate(0, 1, -d.Day())
+}
+
+// ReturnPrice is the price used for return calculations: the total-return
+// (dividend-adjusted) close by default, or the raw close for price return.
+func ReturnPrice(bar data.PriceBar) float64 {
+	if config.UseTotalReturn {
+		return bar.TotalReturnClose()
+	}
+	return bar.Close
+}
+
 func getOrDefault(m map[string]float64, key string, def float64) float64 {
 	if v, ok := m[key]; ok {
 		return v
@@ -451,9 +646,9 @@ func getOrDefault(m map[string]float64, key string, def float64) float64 {
 	return def
 }
 
-func defaultScores() map[string]float64 {
+func defaultScores(sectors []string) map[string]float64 {
 	scores := make(map[string]float64)
-	for _, sector := range config.SectorNames {
+	for _, sector := range sectors {
 		scores[sector] = 50.0
 	}
 	return scores
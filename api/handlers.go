@@ -4,63 +4,134 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+
 	"sector-analyzer/analysis"
 	"sector-analyzer/config"
 	"sector-analyzer/data"
+	"sector-analyzer/portfolio"
+	"sector-analyzer/scenario"
+	"sector-analyzer/storage"
 )
 
-// AppState holds the application state including cached data.
+// AppState holds the application state including cached data per universe.
+// mu guards the cache and is held only briefly; fetches are serialized per
+// universe by fetchLocks so a slow refresh never blocks reads.
 type AppState struct {
 	mu         sync.RWMutex
-	cachedData *data.AllData
+	cachedData map[string]*data.AllData
+	fetchLocks map[string]*sync.Mutex
+	db         *storage.DB
 }
 
 // NewAppState creates a new application state.
 func NewAppState() *AppState {
-	return &AppState{}
+	return &AppState{
+		cachedData: make(map[string]*data.AllData),
+		fetchLocks: make(map[string]*sync.Mutex),
+	}
 }
 
-// GetData returns cached data or fetches fresh data.
-func (s *AppState) GetData() *data.AllData {
+// cached returns the cached data for a universe, or nil.
+func (s *AppState) cached(name string) *data.AllData {
 	s.mu.RLock()
-	if s.cachedData != nil {
-		s.mu.RUnlock()
-		return s.cachedData
-	}
-	s.mu.RUnlock()
+	defer s.mu.RUnlock()
+	return s.cachedData[name]
+}
 
+// fetchLock returns the mutex serializing fetches of a universe.
+func (s *AppState) fetchLock(name string) *sync.Mutex {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Double-check after acquiring write lock
-	if s.cachedData != nil {
-		return s.cachedData
+	lock, ok := s.fetchLocks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.fetchLocks[name] = lock
 	}
+	return lock
+}
+
+// GetData returns cached data for a universe or fetches fresh data.
+func (s *AppState) GetData(u config.SectorUniverse) *data.AllData {
+	if cached := s.cached(u.Name); cached != nil {
+		return cached
+	}
+
+	lock := s.fetchLock(u.Name)
+	lock.Lock()
+	defer lock.Unlock()
 
-	allData, _ := data.FetchAllData()
-	s.cachedData = allData
-	return s.cachedData
+	// Double-check after waiting for any fetch in progress
+	if cached := s.cached(u.Name); cached != nil {
+		return cached
+	}
+	return s.fetch(u, nil)
+}
+
+// RefreshData forces a data refresh for a universe, reporting each step to
+// progress. Cached data keeps being served until the refresh completes, and
+// after it if the refresh fails.
+func (s *AppState) RefreshData(u config.SectorUniverse, progress data.ProgressFunc) *data.AllData {
+	lock := s.fetchLock(u.Name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return s.fetch(u, progress)
 }
 
-// RefreshData forces a data refresh.
-func (s *AppState) RefreshData() *data.AllData {
+// fetch retrieves a universe's data and swaps it into the cache. A failed
+// refresh is returned but leaves any cached data in place and records no
+// snapshot. Caller holds the universe's fetch lock.
+func (s *AppState) fetch(u config.SectorUniverse, progress data.ProgressFunc) *data.AllData {
+	allData, _ := data.FetchUniverseData(u, progress)
+	failed := refreshFailed(allData)
+
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	if _, ok := s.cachedData[u.Name]; !ok || !failed {
+		s.cachedData[u.Name] = allData
+	}
+	s.mu.Unlock()
 
-	allData, _ := data.FetchAllData()
-	s.cachedData = allData
-	return s.cachedData
+	if !failed {
+		s.recordSnapshot(u, allData)
+	}
+	return allData
+}
+
+// recordSnapshot stores the default-weight scores of freshly fetched data.
+func (s *AppState) recordSnapshot(u config.SectorUniverse, allData *data.AllData) {
+	if s.db == nil || allData == nil {
+		return
+	}
+
+	scorer := analysis.NewSectorScorer(nil)
+	scores := scorer.CalculateScores(allData)
+	if len(scores) == 0 {
+		return
+	}
+	if _, err := s.db.SaveScoreSnapshot(u.Name, allData.FetchedAt, scorer.Model, scorer.Weights, scores); err != nil {
+		fmt.Printf("Warning: Could not store score snapshot for %s: %v\n", u.Name, err)
+	}
 }
 
 // Global app state
 var appState = NewAppState()
 
+// SetDatabase enables score snapshots in db. Call before serving requests.
+func SetDatabase(db *storage.DB) {
+	appState.db = db
+}
+
 // JSON helper for writing responses
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -68,11 +139,25 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// parseUniverse resolves the "universe" query parameter. It writes a 404
+// response and returns false when the universe is unknown.
+func parseUniverse(w http.ResponseWriter, r *http.Request) (config.SectorUniverse, bool) {
+	name := r.URL.Query().Get("universe")
+	u, ok := config.LookupUniverse(name)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "unknown_universe",
+			Message: "Universe '" + name + "' not found (available: " + strings.Join(config.UniverseNames(), ", ") + ")",
+		})
+	}
+	return u, ok
+}
+
 // parseWeights extracts scoring weights from query parameters.
 func parseWeights(r *http.Request) map[string]float64 {
 	weights := make(map[string]float64)
 
-	params := []string{"momentum", "valuation", "growth", "innovation", "macro"}
+	params := analysis.SignalNames()
 	hasAny := false
 
 	for _, param := range params {
@@ -89,7 +174,7 @@ func parseWeights(r *http.Request) map[string]float64 {
 	}
 
 	// Fill in defaults for missing weights
-	defaults := config.DefaultWeights
+	defaults := analysis.DefaultWeights()
 	for _, param := range params {
 		if _, ok := weights[param]; !ok {
 			weights[param] = defaults[param]
@@ -110,6 +195,55 @@ func parseWeights(r *http.Request) map[string]float64 {
 	return weights
 }
 
+// parseScorer builds a scorer from the weight, valuation_method,
+// fundamentals_source, model and momentum_indicators query parameters,
+// writing a 400 response and returning false if any is invalid.
+func parseScorer(w http.ResponseWriter, r *http.Request) (*analysis.SectorScorer, bool) {
+	scorer := analysis.NewSectorScorer(parseWeights(r))
+	if method := r.URL.Query().Get("valuation_method"); method != "" {
+		if !slices.Contains(config.ValuationMethods, method) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_valuation_method",
+				Message: "valuation_method must be one of: " + strings.Join(config.ValuationMethods, ", "),
+			})
+			return nil, false
+		}
+		scorer.ValuationMethod = method
+	}
+	if source := r.URL.Query().Get("fundamentals_source"); source != "" {
+		if !slices.Contains(config.FundamentalsSources, source) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_fundamentals_source",
+				Message: "fundamentals_source must be one of: " + strings.Join(config.FundamentalsSources, ", "),
+			})
+			return nil, false
+		}
+		scorer.FundamentalsSource = source
+	}
+	if model := r.URL.Query().Get("model"); model != "" {
+		if !slices.Contains(config.ScoringModels, model) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_model",
+				Message: "model must be one of: " + strings.Join(config.ScoringModels, ", "),
+			})
+			return nil, false
+		}
+		scorer.Model = model
+	}
+	if param := r.URL.Query().Get("momentum_indicators"); param != "" {
+		indicators, err := config.CheckMomentumIndicators(strings.Split(param, ","))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_momentum_indicator",
+				Message: "momentum_indicators must be from: " + strings.Join(config.MomentumIndicatorNames, ", "),
+			})
+			return nil, false
+		}
+		scorer.MomentumIndicators = indicators
+	}
+	return scorer, true
+}
+
 // HealthHandler handles GET /health
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, HealthResponse{
@@ -129,15 +263,25 @@ func RootHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetScoresHandler handles GET /api/scores
 func GetScoresHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
 	// Check for refresh flag
 	refresh := r.URL.Query().Get("refresh") == "true"
 
 	var allData *data.AllData
 	if refresh {
-		allData = appState.RefreshData()
-	} else {
-		allData = appState.GetData()
+		// Run through the job API so concurrent refreshes share one fetch
+		job := refreshJobs.Start(appState, universe)
+		select {
+		case <-job.Done():
+		case <-r.Context().Done():
+			return
+		}
 	}
+	allData = appState.GetData(universe)
 
 	if allData == nil {
 		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
@@ -147,10 +291,33 @@ func GetScoresHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse weights from query params
-	weights := parseWeights(r)
-	scorer := analysis.NewSectorScorer(weights)
-	scores := scorer.CalculateScores(allData)
+	// Score only what was published by the as_of date
+	var asOf *string
+	if param := r.URL.Query().Get("as_of"); param != "" {
+		date, err := time.Parse("2006-01-02", param)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_as_of",
+				Message: "as_of must be a date in YYYY-MM-DD format",
+			})
+			return
+		}
+		allData, err = data.DataAsOf(allData, date, nil)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_as_of",
+				Message: err.Error(),
+			})
+			return
+		}
+		asOf = &param
+	}
+
+	scorer, ok := parseScorer(w, r)
+	if !ok {
+		return
+	}
+	scores, cycle := scorer.CalculateScoresWithCycle(allData)
 
 	// Convert to response format
 	var scoreResponses []SectorScoreResponse
@@ -159,15 +326,119 @@ func GetScoresHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusOK, ScoresResponse{
+		Universe:    universe.Name,
+		Benchmark:   universe.Benchmark,
+		Currency:    allData.Currency,
+		AsOf:        asOf,
+		Model:       scorer.Model,
+		Cycle:       cycle,
 		Scores:      scoreResponses,
 		WeightsUsed: scorer.Weights,
 		Timestamp:   time.Now().Format(time.RFC3339),
 	})
 }
 
+// StartRefreshHandler handles POST /api/refresh
+func StartRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	job := refreshJobs.Start(appState, universe)
+	writeJSON(w, http.StatusAccepted, job.Snapshot())
+}
+
+// GetRefreshJobHandler handles GET /api/refresh/{id}
+func GetRefreshJobHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	job, ok := refreshJobs.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Refresh job '" + id + "' not found",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job.Snapshot())
+}
+
+// RefreshEventsHandler handles GET /api/refresh/{id}/events
+// Streams progress events as Server-Sent Events, replaying events already
+// recorded, and ends with a "done" event carrying the final job status.
+func RefreshEventsHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	job, ok := refreshJobs.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Refresh job '" + id + "' not found",
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "streaming_unsupported",
+			Message: "Server-Sent Events are not supported by this connection",
+		})
+		return
+	}
+
+	// Resume after the last event the client saw
+	next := 0
+	if lastID, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		next = lastID + 1
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		events, notify, finished := job.EventsSince(next)
+		for _, ev := range events {
+			writeSSE(w, next, "progress", ev)
+			next++
+		}
+
+		if finished {
+			writeSSE(w, next, "done", job.Snapshot())
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-notify:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSE writes a single Server-Sent Event with a JSON payload.
+func writeSSE(w http.ResponseWriter, id int, event string, payload interface{}) {
+	body, _ := json.Marshal(payload)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, body)
+}
+
 // GetSummaryHandler handles GET /api/scores/summary
 func GetSummaryHandler(w http.ResponseWriter, r *http.Request) {
-	allData := appState.GetData()
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+	scorer, ok := parseScorer(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
 
 	if allData == nil {
 		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
@@ -177,13 +448,10 @@ func GetSummaryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	weights := parseWeights(r)
-	scores, summary := analysis.RunAnalysis(allData, weights)
-
-	// The summary already has most fields, just use it
-	_ = scores // Used by RunAnalysis to generate summary
+	summary := scorer.GetSummaryReport(scorer.CalculateScores(allData))
 
 	writeJSON(w, http.StatusOK, SummaryResponse{
+		Universe:          universe.Name,
 		TopSectors:        summary.TopSectors,
 		BottomSectors:     summary.BottomSectors,
 		ScoreDistribution: summary.ScoreDistribution,
@@ -207,7 +475,12 @@ func GetSectorScoreHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	sectorName := parts[len(parts)-1]
 
-	allData := appState.GetData()
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
 	if allData == nil {
 		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
 			Error:   "data_unavailable",
@@ -233,13 +506,919 @@ func GetSectorScoreHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetERPHandler handles GET /api/valuation/erp
+// Returns each sector's earnings yield less the 10-year Treasury yield, with
+// its history from stored score snapshots.
+func GetERPHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+
+	var history map[string]data.TimeSeries
+	if appState.db != nil {
+		var err error
+		if history, err = appState.db.ERPHistory(universe.Name); err != nil {
+			fmt.Printf("Warning: Could not load ERP history for %s: %v\n", universe.Name, err)
+		}
+	}
+
+	premiums := analysis.CalculateEquityRiskPremium(allData.SectorInfo, allData.MacroData)
+	resp := ERPResponse{
+		Universe:  universe.Name,
+		Anchor:    config.ERPAnchor,
+		Sectors:   make([]SectorERP, 0, len(allData.Sectors)),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if treasury := allData.MacroData["treasury_10y"]; len(treasury.Values) > 0 {
+		latest := treasury.Values[len(treasury.Values)-1] / 100
+		resp.Treasury10y = &latest
+	}
+
+	for _, sector := range allData.Sectors {
+		entry := SectorERP{Sector: sector, History: []ERPPoint{}}
+		if info := allData.SectorInfo[sector]; info.ForwardPE != nil && *info.ForwardPE > 0 {
+			ey := 1 / *info.ForwardPE
+			entry.ForwardPE = info.ForwardPE
+			entry.EarningsYield = &ey
+		}
+		if erp, ok := premiums[sector]; ok {
+			entry.EquityRiskPremium = &erp
+		}
+		ts := history[sector]
+		for i, date := range ts.Dates {
+			entry.History = append(entry.History, ERPPoint{Date: date.Format("2006-01-02"), Value: ts.Values[i]})
+		}
+		resp.Sectors = append(resp.Sectors, entry)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetTechnicalsHandler handles GET /api/technicals/{sector}
+// Returns the sector ETF's closes and technical indicators for charting,
+// computed over the full history and trimmed to the last days sessions.
+func GetTechnicalsHandler(w http.ResponseWriter, r *http.Request) {
+	sectorName := chi.URLParam(r, "sector")
+
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	days, ok := parsePositiveInt(w, r, "days", 252)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+
+	var def *config.SectorDefinition
+	for i := range universe.Sectors {
+		if strings.EqualFold(universe.Sectors[i].Name, sectorName) {
+			def = &universe.Sectors[i]
+			break
+		}
+	}
+	series := data.PriceSeries(nil)
+	if def != nil {
+		series = allData.SectorPrices[def.Name]
+	}
+	if len(series) == 0 {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "No prices for sector '" + sectorName + "'",
+		})
+		return
+	}
+
+	macd := analysis.MACD(series, 12, 26, 9)
+	bands := analysis.Bollinger(series, 20, 2)
+	indicators := map[string][]float64{
+		"sma_50":           analysis.SMA(series, 50),
+		"sma_200":          analysis.SMA(series, 200),
+		"ema_20":           analysis.EMA(series, 20),
+		"rsi_14":           analysis.RSI(series, 14),
+		"macd":             macd.MACD,
+		"macd_signal":      macd.Signal,
+		"macd_histogram":   macd.Histogram,
+		"bollinger_upper":  bands.Upper,
+		"bollinger_middle": bands.Middle,
+		"bollinger_lower":  bands.Lower,
+		"atr_14":           analysis.ATR(series, 14),
+		"roc_63":           analysis.ROC(series, 63),
+		"trend_slope_126":  analysis.TrendSlope(series, 126),
+	}
+
+	start := 0
+	if len(series) > days {
+		start = len(series) - days
+	}
+	resp := TechnicalsResponse{
+		Universe:   universe.Name,
+		Sector:     def.Name,
+		ETF:        def.ETF,
+		Dates:      make([]string, 0, len(series)-start),
+		Close:      make([]float64, 0, len(series)-start),
+		Indicators: make(map[string][]*float64, len(indicators)),
+		Latest:     make(map[string]*float64, len(indicators)),
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	// Closes on the same basis as the indicators
+	for _, bar := range series[start:] {
+		price := bar.Close
+		if config.UseTotalReturn {
+			price = bar.TotalReturnClose()
+		}
+		resp.Dates = append(resp.Dates, bar.Date.Format("2006-01-02"))
+		resp.Close = append(resp.Close, price)
+	}
+	for name, values := range indicators {
+		points := nullableValues(values[start:])
+		resp.Indicators[name] = points
+		resp.Latest[name] = points[len(points)-1]
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// nullableValues converts NaN indicator values to nil so they encode as null.
+func nullableValues(values []float64) []*float64 {
+	out := make([]*float64, len(values))
+	for i := range values {
+		if !math.IsNaN(values[i]) {
+			out[i] = &values[i]
+		}
+	}
+	return out
+}
+
+// GetCorrelationsHandler handles GET /api/analytics/correlations
+// Returns the correlation and covariance of sector returns over a trailing
+// window, their hierarchical clustering and diversification ratios.
+func GetCorrelationsHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	window, ok := parsePositiveInt(w, r, "window", config.CorrelationWindow)
+	if !ok {
+		return
+	}
+	top, ok := parsePositiveInt(w, r, "top", 3)
+	if !ok {
+		return
+	}
+	minCorrelation, ok := parseFloatInRange(w, r, "min_correlation", config.ClusterCorrelation, -1, 1)
+	if !ok {
+		return
+	}
+	scorer, ok := parseScorer(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+
+	all := analysis.AlignedReturns(allData.SectorPrices, allData.Sectors)
+	returns := all.Window(window)
+	if len(returns.Sectors) < 2 || len(returns.Returns) < 2 {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "insufficient_data",
+			Message: "Not enough overlapping sector prices for correlations",
+		})
+		return
+	}
+
+	corr := returns.Correlation()
+	cov := returns.Covariance()
+	sectors := returns.Sectors
+	index := make(map[string]int, len(sectors))
+	everyone := make([]int, len(sectors))
+	equal := make([]float64, len(sectors))
+	for i, sector := range sectors {
+		index[sector] = i
+		everyone[i] = i
+		equal[i] = 1 / float64(len(sectors))
+	}
+	merges := analysis.ClusterSectors(sectors, corr)
+	clusters := analysis.CutClusters(sectors, merges, minCorrelation)
+
+	resp := CorrelationsResponse{
+		Universe:             universe.Name,
+		Window:               len(returns.Returns),
+		Start:                returns.Dates[0].Format("2006-01-02"),
+		End:                  returns.Dates[len(returns.Dates)-1].Format("2006-01-02"),
+		Sectors:              sectors,
+		Correlation:          corr,
+		Covariance:           cov,
+		Volatility:           analysis.Volatilities(cov),
+		AverageCorrelation:   analysis.AverageCorrelation(corr, everyone),
+		DiversificationRatio: analysis.DiversificationRatio(equal, cov),
+		MinCorrelation:       minCorrelation,
+		Clusters:             clusters,
+		Dendrogram:           merges,
+		History:              []CorrelationPoint{},
+		Timestamp:            time.Now().Format(time.RFC3339),
+	}
+
+	// The top-ranked sectors that have prices, as an equal-weighted basket
+	scores := scorer.CalculateScores(allData)
+	var members []int
+	for _, s := range scores {
+		if i, ok := index[s.Sector]; ok && len(members) < top {
+			members = append(members, i)
+			resp.Top.Sectors = append(resp.Top.Sectors, s.Sector)
+		}
+	}
+	weights := make([]float64, len(sectors))
+	for _, i := range members {
+		weights[i] = 1 / float64(len(members))
+	}
+	resp.Top.DiversificationRatio = analysis.DiversificationRatio(weights, cov)
+	if avg := analysis.AverageCorrelation(corr, members); !math.IsNaN(avg) {
+		resp.Top.AverageCorrelation = &avg
+	}
+	inCluster := make(map[int]bool)
+	for c, cluster := range clusters {
+		for _, sector := range cluster {
+			if slices.Contains(resp.Top.Sectors, sector) {
+				inCluster[c] = true
+			}
+		}
+	}
+	resp.Top.Clusters = len(inCluster)
+
+	history := analysis.RollingAverageCorrelation(all, window, 21)
+	for i, date := range history.Dates {
+		resp.History = append(resp.History, CorrelationPoint{Date: date.Format("2006-01-02"), Value: history.Values[i]})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parsePositiveInt reads an optional positive integer query parameter,
+// writing a 400 response and returning false if it is invalid.
+func parsePositiveInt(w http.ResponseWriter, r *http.Request, name string, fallback int) (int, bool) {
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil || n <= 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_" + name,
+			Message: name + " must be a positive integer",
+		})
+		return 0, false
+	}
+	return n, true
+}
+
+// parseFloatInRange reads an optional float query parameter within [lo, hi],
+// writing a 400 response and returning false if it is invalid.
+func parseFloatInRange(w http.ResponseWriter, r *http.Request, name string, fallback, lo, hi float64) (float64, bool) {
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		return fallback, true
+	}
+	f, err := strconv.ParseFloat(param, 64)
+	if err != nil || math.IsNaN(f) || f < lo || f > hi {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_" + name,
+			Message: fmt.Sprintf("%s must be between %g and %g", name, lo, hi),
+		})
+		return 0, false
+	}
+	return f, true
+}
+
+// GetPortfolioHandler handles GET /api/portfolio
+func GetPortfolioHandler(w http.ResponseWriter, r *http.Request) {
+	_, _, resp, ok := buildPortfolio(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// buildPortfolio constructs target weights from the scoring and portfolio
+// query parameters, writing an error response and returning false if it
+// cannot.
+func buildPortfolio(w http.ResponseWriter, r *http.Request) (config.SectorUniverse, *data.AllData, PortfolioResponse, bool) {
+	var resp PortfolioResponse
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return universe, nil, resp, false
+	}
+	scorer, ok := parseScorer(w, r)
+	if !ok {
+		return universe, nil, resp, false
+	}
+	opts, ok := parsePortfolioOptions(w, r)
+	if !ok {
+		return universe, nil, resp, false
+	}
+	method := r.URL.Query().Get("method")
+	if method == "" {
+		method = "top_n"
+	}
+	if !slices.Contains(config.PortfolioMethods, method) {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_method",
+			Message: "method must be one of: " + strings.Join(config.PortfolioMethods, ", "),
+		})
+		return universe, nil, resp, false
+	}
+	window, ok := parsePositiveInt(w, r, "window", config.CorrelationWindow)
+	if !ok {
+		return universe, nil, resp, false
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return universe, nil, resp, false
+	}
+
+	returns := analysis.AlignedReturns(allData.SectorPrices, allData.Sectors).Window(window)
+	if len(returns.Sectors) < 2 || len(returns.Returns) < 2 {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "insufficient_data",
+			Message: "Not enough overlapping sector prices for a covariance",
+		})
+		return universe, nil, resp, false
+	}
+
+	alloc, err := portfolio.Build(method, scorer.CalculateScores(allData), returns, opts)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "infeasible_portfolio",
+			Message: err.Error(),
+		})
+		return universe, nil, resp, false
+	}
+
+	resp = PortfolioResponse{
+		Universe:   universe.Name,
+		Allocation: alloc,
+		Window:     len(returns.Returns),
+		Start:      returns.Dates[0].Format("2006-01-02"),
+		End:        returns.Dates[len(returns.Dates)-1].Format("2006-01-02"),
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	return universe, allData, resp, true
+}
+
+// RebalanceHandler handles POST /api/portfolio/rebalance
+// The body holds the current sector weights; the target is built from the
+// same query parameters as GET /api/portfolio.
+func RebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	req := RebalanceRequest{
+		NoTradeBand: config.NoTradeBand,
+		Costs:       portfolio.DefaultCostModel(),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_body",
+			Message: "Request body must be JSON with current weights: " + err.Error(),
+		})
+		return
+	}
+	if message := validateRebalance(req); message != "" {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_rebalance",
+			Message: message,
+		})
+		return
+	}
+
+	universe, allData, target, ok := buildPortfolio(w, r)
+	if !ok {
+		return
+	}
+	for sector := range req.Current {
+		if _, found := universe.Sector(sector); !found {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_sector",
+				Message: "Sector '" + sector + "' is not in universe " + universe.Name,
+			})
+			return
+		}
+	}
+
+	targets := make(map[string]float64, len(target.Weights))
+	for _, sw := range target.Weights {
+		targets[sw.Sector] = sw.Weight
+	}
+	var positions []portfolio.Position
+	for _, s := range universe.Sectors {
+		p := portfolio.Position{
+			Sector:  s.Name,
+			ETF:     s.ETF,
+			Current: req.Current[s.Name],
+			Target:  targets[s.Name],
+		}
+		if series := allData.SectorPrices[s.Name]; len(series) > 0 {
+			p.Price = series[len(series)-1].Close
+		}
+		positions = append(positions, p)
+	}
+
+	writeJSON(w, http.StatusOK, RebalanceResponse{
+		Universe:       universe.Name,
+		Method:         target.Method,
+		PortfolioValue: req.PortfolioValue,
+		NoTradeBand:    req.NoTradeBand,
+		Costs:          req.Costs,
+		Proposal:       portfolio.Rebalance(positions, req.PortfolioValue, req.NoTradeBand, req.Costs),
+		Timestamp:      time.Now().Format(time.RFC3339),
+	})
+}
+
+// validateRebalance returns a description of what is wrong with a rebalance
+// request, or "" if it is valid.
+func validateRebalance(r RebalanceRequest) string {
+	if r.PortfolioValue <= 0 {
+		return "portfolio_value must be positive"
+	}
+	if r.NoTradeBand < 0 || r.NoTradeBand >= 1 {
+		return "no_trade_band must be between 0 and 1"
+	}
+	if r.Costs.SpreadBps < 0 || r.Costs.CommissionBps < 0 || r.Costs.MinCommission < 0 {
+		return "costs must not be negative"
+	}
+	var total float64
+	for sector, weight := range r.Current {
+		if weight < 0 {
+			return "current weight of " + sector + " is negative"
+		}
+		total += weight
+	}
+	if total > 1+1e-6 {
+		return fmt.Sprintf("current weights sum to %.4f, more than 1", total)
+	}
+	return ""
+}
+
+// parsePortfolioOptions reads the top, min_weight, max_weight,
+// risk_aversion, max_tracking_error and benchmark query parameters.
+// benchmark is a comma-separated list of sector:weight pairs.
+func parsePortfolioOptions(w http.ResponseWriter, r *http.Request) (portfolio.Options, bool) {
+	opts := portfolio.DefaultOptions()
+	var ok bool
+	if opts.TopN, ok = parsePositiveInt(w, r, "top", opts.TopN); !ok {
+		return opts, false
+	}
+	if opts.MinWeight, ok = parseFloatInRange(w, r, "min_weight", opts.MinWeight, 0, 1); !ok {
+		return opts, false
+	}
+	if opts.MaxWeight, ok = parseFloatInRange(w, r, "max_weight", opts.MaxWeight, 0, 1); !ok {
+		return opts, false
+	}
+	if opts.RiskAversion, ok = parseFloatInRange(w, r, "risk_aversion", opts.RiskAversion, 0, 100); !ok {
+		return opts, false
+	}
+	if opts.MaxTrackingError, ok = parseFloatInRange(w, r, "max_tracking_error", 0, 0, 1); !ok {
+		return opts, false
+	}
+
+	if opts.Benchmark, ok = parsePairs(w, r, "benchmark", "sector:weight"); !ok {
+		return opts, false
+	}
+	for _, weight := range opts.Benchmark {
+		if weight < 0 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_benchmark",
+				Message: "benchmark weights must not be negative",
+			})
+			return opts, false
+		}
+	}
+	return opts, true
+}
+
+// parsePairs reads an optional query parameter of comma-separated
+// name:value pairs, such as "Energy:0.3,Financials:0.2", writing a 400
+// response and returning false if it is malformed. It returns nil when the
+// parameter is absent.
+func parsePairs(w http.ResponseWriter, r *http.Request, name, format string) (map[string]float64, bool) {
+	param := r.URL.Query().Get(name)
+	if param == "" {
+		return nil, true
+	}
+	pairs := make(map[string]float64)
+	for _, pair := range strings.Split(param, ",") {
+		key, value, found := strings.Cut(pair, ":")
+		f, err := strconv.ParseFloat(value, 64)
+		if !found || err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_" + name,
+				Message: name + " must be comma-separated " + format + " pairs",
+			})
+			return nil, false
+		}
+		pairs[strings.TrimSpace(key)] = f
+	}
+	return pairs, true
+}
+
+// parseScenarioPortfolio reads the portfolio query parameter of the
+// scenario endpoints, checking that each sector is in universe.
+func parseScenarioPortfolio(w http.ResponseWriter, r *http.Request, universe config.SectorUniverse) (map[string]float64, bool) {
+	weights, ok := parsePairs(w, r, "portfolio", "sector:weight")
+	if !ok {
+		return nil, false
+	}
+	for sector := range weights {
+		if _, found := universe.Sector(sector); !found {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_sector",
+				Message: "Sector '" + sector + "' is not in universe " + universe.Name,
+			})
+			return nil, false
+		}
+	}
+	return weights, true
+}
+
+// estimateScenarioModel fits the scenario factor model over the months
+// query parameter, writing an error response and returning nil on failure.
+func estimateScenarioModel(w http.ResponseWriter, r *http.Request, allData *data.AllData) *scenario.Model {
+	months, ok := parsePositiveInt(w, r, "months", config.ScenarioMonths)
+	if !ok {
+		return nil
+	}
+	model, err := scenario.Estimate(allData, months)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "insufficient_data",
+			Message: "Cannot estimate sector factor exposures: " + err.Error(),
+		})
+		return nil
+	}
+	return model
+}
+
+// GetScenariosHandler handles GET /api/scenarios
+// Lists the shockable factors, preset scenarios and historical windows, with
+// each sector's estimated factor exposures.
+func GetScenariosHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+	model := estimateScenarioModel(w, r, allData)
+	if model == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ScenariosResponse{
+		Universe:  universe.Name,
+		Factors:   config.ScenarioFactors,
+		Presets:   config.ScenarioPresets,
+		Windows:   config.HistoricalWindows,
+		Model:     model,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// GetStressHandler handles GET /api/scenarios/stress
+func GetStressHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+	scorer, ok := parseScorer(w, r)
+	if !ok {
+		return
+	}
+	weights, ok := parseScenarioPortfolio(w, r, universe)
+	if !ok {
+		return
+	}
+
+	name := r.URL.Query().Get("scenario")
+	shocks, ok := parsePairs(w, r, "shock", "factor:move")
+	if !ok {
+		return
+	}
+	if name != "" {
+		preset, found := config.ScenarioPresets[name]
+		if !found {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_scenario",
+				Message: "scenario must be one of: " + strings.Join(sortedKeys(config.ScenarioPresets), ", "),
+			})
+			return
+		}
+		// Explicit shocks override the preset's
+		merged := make(map[string]float64, len(preset)+len(shocks))
+		for f, v := range preset {
+			merged[f] = v
+		}
+		for f, v := range shocks {
+			merged[f] = v
+		}
+		shocks = merged
+	}
+	if len(shocks) == 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "missing_shock",
+			Message: "Give a scenario or shocks, e.g. shock=treasury_10y:1,market:-0.2",
+		})
+		return
+	}
+	for f := range shocks {
+		if !slices.Contains(config.ScenarioFactors, f) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_shock",
+				Message: "shock factors must be from: " + strings.Join(config.ScenarioFactors, ", "),
+			})
+			return
+		}
+	}
+	propagate := r.URL.Query().Get("propagate") != "false"
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+	model := estimateScenarioModel(w, r, allData)
+	if model == nil {
+		return
+	}
+	moves, err := model.Moves(shocks, propagate)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_shock",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StressResponse{
+		Universe:  universe.Name,
+		Scenario:  name,
+		Shocks:    shocks,
+		Propagate: propagate,
+		Start:     model.Start.Format("2006-01"),
+		End:       model.End.Format("2006-01"),
+		Months:    model.Months,
+		Stress:    model.Run(allData, scorer, moves, weights),
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// GetReplayHandler handles GET /api/scenarios/replay
+// Replays the named windows (all of them by default) or a custom from/to
+// range over the stored price history.
+func GetReplayHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+	weights, ok := parseScenarioPortfolio(w, r, universe)
+	if !ok {
+		return
+	}
+
+	windows := config.HistoricalWindows
+	requested := false
+	if from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to"); from != "" || to != "" {
+		windows = []config.HistoricalWindow{{Name: "custom", From: from, To: to}}
+		requested = true
+	} else if param := r.URL.Query().Get("window"); param != "" {
+		windows = nil
+		requested = true
+		for _, name := range strings.Split(param, ",") {
+			i := slices.IndexFunc(config.HistoricalWindows, func(hw config.HistoricalWindow) bool { return hw.Name == name })
+			if i < 0 {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_window",
+					Message: "Window '" + name + "' not found; see GET /api/scenarios",
+				})
+				return
+			}
+			windows = append(windows, config.HistoricalWindows[i])
+		}
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+
+	// Fetch the history back to the earliest window, holding the fetch
+	// lock so a concurrent refresh doesn't overwrite the stored backfill
+	start := time.Now()
+	for _, window := range windows {
+		if from, err := time.Parse("2006-01-02", window.From); err == nil && from.Before(start) {
+			start = from
+		}
+	}
+	lock := appState.fetchLock(universe.Name)
+	lock.Lock()
+	prices, err := data.FetchPriceHistory(universe, start.AddDate(0, 0, -10))
+	lock.Unlock()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch price history: " + err.Error(),
+		})
+		return
+	}
+
+	resp := ReplayResponse{
+		Universe:  universe.Name,
+		Replays:   []scenario.Replay{},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	for _, window := range windows {
+		replay, err := scenario.ReplayWindow(allData, prices, window, weights)
+		if err != nil {
+			if resp.Skipped == nil {
+				resp.Skipped = make(map[string]string)
+			}
+			resp.Skipped[window.Name] = err.Error()
+			continue
+		}
+		resp.Replays = append(resp.Replays, replay)
+	}
+	if requested && len(resp.Replays) == 0 {
+		var reasons []string
+		for name, reason := range resp.Skipped {
+			reasons = append(reasons, name+": "+reason)
+		}
+		sort.Strings(reasons)
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error:   "window_unavailable",
+			Message: strings.Join(reasons, "; "),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // GetSectorsHandler handles GET /api/data/sectors
 func GetSectorsHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
 	writeJSON(w, http.StatusOK, SectorListResponse{
-		Sectors: config.SectorNames,
+		Universe: universe.Name,
+		Sectors:  universe.SectorNames(),
 	})
 }
 
+// GetHoldingsHandler handles GET /api/data/holdings
+// Returns each sector's metrics aggregated from its ETF holdings, the breadth
+// of its constituents and its ten largest holdings.
+func GetHoldingsHandler(w http.ResponseWriter, r *http.Request) {
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
+	if allData == nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error:   "data_unavailable",
+			Message: "Failed to fetch sector data",
+		})
+		return
+	}
+
+	resp := HoldingsResponse{
+		Universe:  universe.Name,
+		Sectors:   make([]SectorHoldings, 0, len(universe.Sectors)),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	breadth := analysis.CalculateBreadth(allData.Holdings, allData.ConstituentPrices)
+	for _, def := range universe.Sectors {
+		entry := SectorHoldings{Sector: def.Name, ETF: def.ETF, TopHoldings: []data.Holding{}}
+		if lt, ok := allData.LookThrough[def.Name]; ok {
+			entry.LookThrough = &lt
+		}
+		if b, ok := breadth[def.Name]; ok {
+			entry.Breadth = &b
+		}
+		holdings := allData.Holdings[def.Name]
+		if len(holdings) > 10 {
+			holdings = holdings[:10]
+		}
+		entry.TopHoldings = append(entry.TopHoldings, holdings...)
+		resp.Sectors = append(resp.Sectors, entry)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetUniversesHandler handles GET /api/data/universes
+func GetUniversesHandler(w http.ResponseWriter, r *http.Request) {
+	var universes []UniverseResponse
+	for _, name := range config.UniverseNames() {
+		u := config.Universes[name]
+		resp := UniverseResponse{
+			Name:        u.Name,
+			Description: u.Description,
+			Benchmark:   u.Benchmark,
+			Currency:    u.Currency,
+			ConvertTo:   u.ConvertTo,
+			Default:     name == config.DefaultUniverseName,
+		}
+		for _, s := range u.Sectors {
+			resp.Sectors = append(resp.Sectors, UniverseSectorResponse{
+				Name:     s.Name,
+				ETF:      s.ETF,
+				Currency: u.SectorCurrency(s),
+			})
+		}
+		universes = append(universes, resp)
+	}
+
+	writeJSON(w, http.StatusOK, UniverseListResponse{Universes: universes})
+}
+
+// GetSignalsHandler handles GET /api/data/signals
+// Lists the signals scores are built from, each name also a weight
+// parameter of /api/scores, and the metrics custom signals can use.
+func GetSignalsHandler(w http.ResponseWriter, r *http.Request) {
+	defaults := analysis.DefaultWeights()
+	var signals []SignalResponse
+	for _, sig := range analysis.Signals() {
+		resp := SignalResponse{
+			Name:          sig.Name(),
+			Inputs:        sig.Inputs(),
+			DefaultWeight: defaults[sig.Name()],
+		}
+		if expr, ok := sig.(*analysis.ExpressionSignal); ok {
+			resp.Description = expr.Description()
+			resp.Expression = expr.Expression()
+			resp.Normalize = expr.Normalize()
+		}
+		signals = append(signals, resp)
+	}
+
+	writeJSON(w, http.StatusOK, SignalListResponse{Signals: signals, Metrics: analysis.MetricNames()})
+}
+
 // DataSourceStatus represents the status of a data source.
 type DataSourceStatus struct {
 	Name    string  `json:"name"`
@@ -247,16 +1426,32 @@ type DataSourceStatus struct {
 	Message *string `json:"message,omitempty"`
 }
 
+// TickerQuality lists validation issues found in one ticker or series.
+type TickerQuality struct {
+	Ticker   string           `json:"ticker"`
+	Status   string           `json:"status"`
+	Repaired int              `json:"repaired"`
+	Issues   []data.DataIssue `json:"issues"`
+}
+
 // DataQualityResponse contains data quality info for all sources.
+// Industries lists Damodaran industries the universe's sectors do not match.
 type DataQualityResponse struct {
-	Sources       []DataSourceStatus `json:"sources"`
-	OverallStatus string             `json:"overall_status"`
+	Sources       []DataSourceStatus     `json:"sources"`
+	Tickers       []TickerQuality        `json:"tickers"`
+	Industries    *data.IndustryCoverage `json:"industries,omitempty"`
+	OverallStatus string                 `json:"overall_status"`
 }
 
 // GetDataQualityHandler handles GET /api/data/quality
 // Deep validation: checks that data is not just present but actually usable.
 func GetDataQualityHandler(w http.ResponseWriter, r *http.Request) {
-	allData := appState.GetData()
+	universe, ok := parseUniverse(w, r)
+	if !ok {
+		return
+	}
+
+	allData := appState.GetData(universe)
 
 	sources := []DataSourceStatus{
 		{Name: "Yahoo Finance", Status: "pending"},
@@ -273,11 +1468,24 @@ func GetDataQualityHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		writeJSON(w, http.StatusOK, DataQualityResponse{
 			Sources:       sources,
+			Tickers:       []TickerQuality{},
 			OverallStatus: "error",
 		})
 		return
 	}
 
+	// Thresholds scale with the universe size (10 and 8 of the 11 GICS sectors)
+	sectorCount := len(universe.Sectors)
+	blsCount := 0
+	for _, def := range universe.Sectors {
+		if def.BLSSeries != "" {
+			blsCount++
+		}
+	}
+	mostPrices := atLeast(0.9, sectorCount)
+	mostSectors := atLeast(0.7, sectorCount)
+	mostBLS := atLeast(0.7, blsCount)
+
 	// Check Yahoo Finance: prices AND PE data
 	priceSectors := 0
 	peSectors := 0
@@ -295,11 +1503,11 @@ func GetDataQualityHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if priceSectors >= 10 && peSectors >= 8 {
+	if priceSectors >= mostPrices && peSectors >= mostSectors {
 		sources[0].Status = "ok"
 		msg := fmt.Sprintf("%d sectors with prices, %d with P/E data", priceSectors, peSectors)
 		sources[0].Message = &msg
-	} else if priceSectors >= 10 && peSectors == 0 {
+	} else if priceSectors >= mostPrices && peSectors == 0 {
 		sources[0].Status = "warning"
 		msg := fmt.Sprintf("%d sectors with prices, but P/E data unavailable", priceSectors)
 		sources[0].Message = &msg
@@ -350,7 +1558,7 @@ func GetDataQualityHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if blsTotal >= 8 && blsSufficient >= 8 {
+	if blsTotal >= mostBLS && blsSufficient >= mostBLS && blsSufficient > 0 {
 		sources[2].Status = "ok"
 		msg := fmt.Sprintf("%d/%d sectors with sufficient history", blsSufficient, blsTotal)
 		sources[2].Message = &msg
@@ -375,7 +1583,7 @@ func GetDataQualityHandler(w http.ResponseWriter, r *http.Request) {
 			nonZeroRD++
 		}
 	}
-	if nonZeroRD >= 8 {
+	if nonZeroRD >= mostSectors {
 		sources[3].Status = "ok"
 		msg := fmt.Sprintf("%d/%d sectors with R&D data", nonZeroRD, len(allData.RDData))
 		sources[3].Message = &msg
@@ -403,10 +1611,36 @@ func GetDataQualityHandler(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, DataQualityResponse{
 		Sources:       sources,
+		Tickers:       tickerQuality(allData.Issues),
+		Industries:    allData.Industries,
 		OverallStatus: overall,
 	})
 }
 
+// tickerQuality summarizes validation issues per ticker, sorted by ticker.
+// Tickers whose issues were all repaired are "ok"; the rest are "warning".
+func tickerQuality(issues data.DataIssues) []TickerQuality {
+	tickers := make([]TickerQuality, 0, len(issues))
+	for ticker, found := range issues {
+		tq := TickerQuality{Ticker: ticker, Status: "ok", Issues: found}
+		for _, issue := range found {
+			if issue.Repaired {
+				tq.Repaired++
+			} else {
+				tq.Status = "warning"
+			}
+		}
+		tickers = append(tickers, tq)
+	}
+	sort.Slice(tickers, func(i, j int) bool { return tickers[i].Ticker < tickers[j].Ticker })
+	return tickers
+}
+
+// atLeast returns the count needed for a fraction of n, rounded up.
+func atLeast(fraction float64, n int) int {
+	return int(math.Ceil(fraction * float64(n)))
+}
+
 // GetCacheInfoHandler handles GET /api/cache/info
 func GetCacheInfoHandler(w http.ResponseWriter, r *http.Request) {
 	info := data.GlobalCache.Info()
@@ -0,0 +1,216 @@
+// Asynchronous data refresh jobs.
+
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// Refresh job statuses.
+const (
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// maxFinishedJobs bounds how many completed jobs are kept for status lookups.
+const maxFinishedJobs = 20
+
+// RefreshJob tracks a single background data refresh.
+type RefreshJob struct {
+	ID        string
+	Universe  string
+	StartedAt time.Time
+
+	mu            sync.Mutex
+	status        string
+	finishedAt    time.Time
+	failedSources map[string]string
+	events        []data.ProgressEvent
+	notify        chan struct{}
+	done          chan struct{}
+}
+
+// publish records a progress event and wakes any streaming subscribers.
+func (j *RefreshJob) publish(ev data.ProgressEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.events = append(j.events, ev)
+	close(j.notify)
+	j.notify = make(chan struct{})
+}
+
+// finish marks the job complete, or failed when the refresh produced
+// nothing to score, and releases waiters.
+func (j *RefreshJob) finish(allData *data.AllData) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.finishedAt = time.Now()
+	j.status = JobCompleted
+	if allData != nil {
+		j.failedSources = allData.FailedSources
+	}
+	if refreshFailed(allData) {
+		j.status = JobFailed
+	}
+	close(j.notify)
+	j.notify = make(chan struct{})
+	close(j.done)
+}
+
+// refreshFailed reports whether fetched data is unusable: no prices were
+// fetched, which also covers every source failing.
+func refreshFailed(allData *data.AllData) bool {
+	if allData == nil || len(allData.SectorPrices) == 0 {
+		return true
+	}
+	_, failed := allData.FailedSources[data.SourceYahooPrices]
+	return failed
+}
+
+// EventsSince returns events after index from, a channel that is closed on
+// the next update, and whether the job has finished.
+func (j *RefreshJob) EventsSince(from int) ([]data.ProgressEvent, <-chan struct{}, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var events []data.ProgressEvent
+	if from < len(j.events) {
+		events = append(events, j.events[from:]...)
+	}
+
+	finished := false
+	select {
+	case <-j.done:
+		finished = true
+	default:
+	}
+
+	return events, j.notify, finished
+}
+
+// Done returns a channel closed when the job finishes.
+func (j *RefreshJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Snapshot returns the job's current status.
+func (j *RefreshJob) Snapshot() RefreshJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := RefreshJobStatus{
+		ID:            j.ID,
+		Universe:      j.Universe,
+		Status:        j.status,
+		StartedAt:     j.StartedAt.Format(time.RFC3339),
+		EventCount:    len(j.events),
+		FailedSources: j.failedSources,
+	}
+	if status.FailedSources == nil {
+		status.FailedSources = map[string]string{}
+	}
+	if !j.finishedAt.IsZero() {
+		finished := j.finishedAt.Format(time.RFC3339)
+		status.FinishedAt = &finished
+	}
+	return status
+}
+
+// JobManager runs at most one refresh job per universe and keeps recent results.
+type JobManager struct {
+	mu      sync.Mutex
+	jobs    map[string]*RefreshJob
+	current map[string]*RefreshJob
+}
+
+// NewJobManager creates an empty job manager.
+func NewJobManager() *JobManager {
+	return &JobManager{
+		jobs:    make(map[string]*RefreshJob),
+		current: make(map[string]*RefreshJob),
+	}
+}
+
+// Start launches a refresh job for a universe, or returns the one already running.
+func (m *JobManager) Start(state *AppState, u config.SectorUniverse) *RefreshJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job, ok := m.current[u.Name]; ok {
+		return job
+	}
+
+	job := &RefreshJob{
+		ID:        newJobID(),
+		Universe:  u.Name,
+		StartedAt: time.Now(),
+		status:    JobRunning,
+		notify:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	m.jobs[job.ID] = job
+	m.current[u.Name] = job
+	m.prune()
+
+	go func() {
+		allData := state.RefreshData(u, job.publish)
+		job.finish(allData)
+
+		m.mu.Lock()
+		delete(m.current, u.Name)
+		m.mu.Unlock()
+	}()
+
+	return job
+}
+
+// Get returns a job by ID.
+func (m *JobManager) Get(id string) (*RefreshJob, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// prune drops the oldest finished jobs beyond maxFinishedJobs. Caller holds m.mu.
+func (m *JobManager) prune() {
+	var finished []*RefreshJob
+	for _, job := range m.jobs {
+		if m.current[job.Universe] != job {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].StartedAt.Before(finished[j].StartedAt)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, job.ID)
+	}
+}
+
+// newJobID returns a random hex identifier.
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Global refresh job manager
+var refreshJobs = NewJobManager()
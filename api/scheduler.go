@@ -0,0 +1,48 @@
+// The scheduled post-close data refresh.
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"sector-analyzer/calendar"
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// StartScheduler refreshes every universe once delay has passed after each
+// NYSE session close, so scores reflect the day's closing prices. Holidays
+// and early closes follow the exchange calendar.
+func StartScheduler(delay time.Duration) {
+	go func() {
+		for {
+			next := nextRefresh(time.Now(), delay)
+			fmt.Printf("Next scheduled refresh at %s\n", next.Format(time.RFC1123))
+			time.Sleep(time.Until(next))
+			runScheduledRefresh()
+		}
+	}()
+}
+
+// nextRefresh returns the first time after now that is delay past a session
+// close. Starting between a close and its refresh still refreshes that day.
+func nextRefresh(now time.Time, delay time.Duration) time.Time {
+	return calendar.NYSE.NextClose(now.Add(-delay)).Add(delay)
+}
+
+// runScheduledRefresh clears cached source data and refreshes each universe
+// in turn, waiting for each job so the data sources are not hit in parallel.
+func runScheduledRefresh() {
+	removed := data.GlobalCache.Clear()
+	fmt.Printf("Scheduled refresh: cleared %d cache entries\n", removed)
+
+	for _, name := range config.UniverseNames() {
+		job := refreshJobs.Start(appState, config.Universes[name])
+		<-job.Done()
+
+		status := job.Snapshot()
+		fmt.Printf("Scheduled refresh of %s %s (%d failed sources)\n",
+			name, status.Status, len(status.FailedSources))
+	}
+}
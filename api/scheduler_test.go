@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"sector-analyzer/calendar"
+)
+
+func TestNextRefresh(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		d, err := time.ParseInLocation("2006-01-02 15:04", value, calendar.NYSE.Location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	const delay = 30 * time.Minute
+
+	tests := []struct {
+		name string
+		now  string
+		want string
+	}{
+		{"before the close", "2024-06-04 10:00", "2024-06-04 16:30"},
+		{"inside the delay window", "2024-06-04 16:10", "2024-06-04 16:30"},
+		{"at the close", "2024-06-04 16:00", "2024-06-04 16:30"},
+		{"after the refresh", "2024-06-04 16:30", "2024-06-05 16:30"},
+		{"Friday evening", "2024-06-07 18:00", "2024-06-10 16:30"},
+		{"weekend", "2024-06-08 12:00", "2024-06-10 16:30"},
+		{"before a holiday", "2024-03-28 17:00", "2024-04-01 16:30"},
+		{"early close", "2024-11-29 13:15", "2024-11-29 13:30"},
+		{"after an early close refresh", "2024-11-29 13:45", "2024-12-02 16:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextRefresh(at(tt.now), delay)
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("nextRefresh(%s) = %s, want %s", tt.now, got.Format("2006-01-02 15:04"), tt.want)
+			}
+		})
+	}
+}
@@ -1,29 +1,97 @@
 // Package api provides HTTP handlers and response schemas.
 package api
 
-import "sector-analyzer/analysis"
+import (
+	"bytes"
+	"encoding/json"
+
+	"sector-analyzer/analysis"
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+	"sector-analyzer/portfolio"
+	"sector-analyzer/scenario"
+)
 
 // SectorScoreResponse is the JSON response for a single sector score.
+// Components are written as "<signal>_score" fields after the rank, in
+// signal registration order; MarshalJSON lists every field, so the tags
+// below only document the names.
 type SectorScoreResponse struct {
-	Sector           string   `json:"sector"`
-	OpportunityScore float64  `json:"opportunity_score"`
-	Rank             int      `json:"rank"`
-	MomentumScore    float64  `json:"momentum_score"`
-	ValuationScore   float64  `json:"valuation_score"`
-	GrowthScore      float64  `json:"growth_score"`
-	InnovationScore  float64  `json:"innovation_score"`
-	MacroScore       float64  `json:"macro_score"`
-	PriceReturn3Mo   *float64 `json:"price_return_3mo"`
-	PriceReturn6Mo   *float64 `json:"price_return_6mo"`
-	PriceReturn12Mo  *float64 `json:"price_return_12mo"`
-	RelativeStrength *float64 `json:"relative_strength"`
-	ForwardPE        *float64 `json:"forward_pe"`
-	EmploymentGrowth *float64 `json:"employment_growth"`
-	RDIntensity      *float64 `json:"rd_intensity"`
+	Sector            string             `json:"sector"`
+	OpportunityScore  float64            `json:"opportunity_score"`
+	Rank              int                `json:"rank"`
+	Components        map[string]float64 `json:"-"`
+	FactorScore       float64            `json:"factor_score"`
+	CycleScore        float64            `json:"cycle_score"`
+	PriceReturn3Mo    *float64           `json:"price_return_3mo"`
+	PriceReturn6Mo    *float64           `json:"price_return_6mo"`
+	PriceReturn12Mo   *float64           `json:"price_return_12mo"`
+	RelativeStrength  *float64           `json:"relative_strength"`
+	ForwardPE         *float64           `json:"forward_pe"`
+	EquityRiskPremium *float64           `json:"equity_risk_premium"`
+	EmploymentGrowth  *float64           `json:"employment_growth"`
+	RDIntensity       *float64           `json:"rd_intensity"`
+}
+
+// MarshalJSON writes the response with its components flattened in.
+func (s SectorScoreResponse) MarshalJSON() ([]byte, error) {
+	type field struct {
+		key   string
+		value any
+	}
+	fields := []field{
+		{"sector", s.Sector},
+		{"opportunity_score", s.OpportunityScore},
+		{"rank", s.Rank},
+	}
+	for _, name := range analysis.SignalNames() {
+		if value, ok := s.Components[name]; ok {
+			fields = append(fields, field{name + "_score", value})
+		}
+	}
+	fields = append(fields,
+		field{"factor_score", s.FactorScore},
+		field{"cycle_score", s.CycleScore},
+		field{"price_return_3mo", s.PriceReturn3Mo},
+		field{"price_return_6mo", s.PriceReturn6Mo},
+		field{"price_return_12mo", s.PriceReturn12Mo},
+		field{"relative_strength", s.RelativeStrength},
+		field{"forward_pe", s.ForwardPE},
+		field{"equity_risk_premium", s.EquityRiskPremium},
+		field{"employment_growth", s.EmploymentGrowth},
+		field{"rd_intensity", s.RDIntensity},
+	)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
 
 // ScoresResponse is the JSON response for all sector scores.
 type ScoresResponse struct {
+	Universe    string                `json:"universe"`
+	Benchmark   string                `json:"benchmark"`
+	Currency    string                `json:"currency"`
+	AsOf        *string               `json:"as_of,omitempty"`
+	Model       string                `json:"model"`
+	Cycle       *analysis.CycleState  `json:"cycle,omitempty"`
 	Scores      []SectorScoreResponse `json:"scores"`
 	WeightsUsed map[string]float64    `json:"weights_used"`
 	Timestamp   string                `json:"timestamp"`
@@ -31,6 +99,7 @@ type ScoresResponse struct {
 
 // SummaryResponse is the JSON response for summary report.
 type SummaryResponse struct {
+	Universe          string                         `json:"universe"`
 	TopSectors        []analysis.SectorRank          `json:"top_sectors"`
 	BottomSectors     []analysis.SectorRank          `json:"bottom_sectors"`
 	ScoreDistribution analysis.ScoreDistribution     `json:"score_distribution"`
@@ -41,7 +110,49 @@ type SummaryResponse struct {
 
 // SectorListResponse contains list of available sectors.
 type SectorListResponse struct {
-	Sectors []string `json:"sectors"`
+	Universe string   `json:"universe"`
+	Sectors  []string `json:"sectors"`
+}
+
+// UniverseSectorResponse is a sector and its ETF within a universe.
+type UniverseSectorResponse struct {
+	Name     string `json:"name"`
+	ETF      string `json:"etf"`
+	Currency string `json:"currency"`
+}
+
+// UniverseResponse describes one configured universe.
+type UniverseResponse struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	Benchmark   string                   `json:"benchmark"`
+	Currency    string                   `json:"currency"`
+	ConvertTo   string                   `json:"convert_to,omitempty"`
+	Default     bool                     `json:"default"`
+	Sectors     []UniverseSectorResponse `json:"sectors"`
+}
+
+// UniverseListResponse contains all configured universes.
+type UniverseListResponse struct {
+	Universes []UniverseResponse `json:"universes"`
+}
+
+// SignalResponse describes one registered scoring signal. Expression,
+// Normalize and Description are set for custom expression signals.
+type SignalResponse struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	Expression    string   `json:"expression,omitempty"`
+	Normalize     string   `json:"normalize,omitempty"`
+	Inputs        []string `json:"inputs"`
+	DefaultWeight float64  `json:"default_weight"`
+}
+
+// SignalListResponse contains all registered signals, in scoring order, and
+// the metrics custom signal expressions can use.
+type SignalListResponse struct {
+	Signals []SignalResponse `json:"signals"`
+	Metrics []string         `json:"metrics"`
 }
 
 // CacheInfoResponse contains cache statistics.
@@ -58,12 +169,185 @@ type CacheClearResponse struct {
 	Message      string `json:"message"`
 }
 
+// RefreshJobStatus is the JSON view of a background refresh job.
+type RefreshJobStatus struct {
+	ID            string            `json:"id"`
+	Universe      string            `json:"universe"`
+	Status        string            `json:"status"`
+	StartedAt     string            `json:"started_at"`
+	FinishedAt    *string           `json:"finished_at,omitempty"`
+	EventCount    int               `json:"event_count"`
+	FailedSources map[string]string `json:"failed_sources"`
+}
+
 // HealthResponse is the health check response.
 type HealthResponse struct {
 	Status  string `json:"status"`
 	Version string `json:"version"`
 }
 
+// ERPPoint is a sector's equity risk premium on one date.
+type ERPPoint struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// SectorERP is a sector's current equity risk premium and its history from
+// stored score snapshots.
+type SectorERP struct {
+	Sector            string     `json:"sector"`
+	ForwardPE         *float64   `json:"forward_pe"`
+	EarningsYield     *float64   `json:"earnings_yield"`
+	EquityRiskPremium *float64   `json:"equity_risk_premium"`
+	History           []ERPPoint `json:"history"`
+}
+
+// ERPResponse is the JSON response for sector equity risk premiums.
+type ERPResponse struct {
+	Universe    string      `json:"universe"`
+	Treasury10y *float64    `json:"treasury_10y"`
+	Anchor      float64     `json:"anchor"`
+	Sectors     []SectorERP `json:"sectors"`
+	Timestamp   string      `json:"timestamp"`
+}
+
+// SectorHoldings is a sector's look-through metrics, constituent breadth and
+// largest holdings. LookThrough and Breadth are nil when no holdings file was
+// loaded for the sector.
+type SectorHoldings struct {
+	Sector      string                  `json:"sector"`
+	ETF         string                  `json:"etf"`
+	LookThrough *data.LookThrough       `json:"look_through"`
+	Breadth     *analysis.SectorBreadth `json:"breadth"`
+	TopHoldings []data.Holding          `json:"top_holdings"`
+}
+
+// HoldingsResponse is the JSON response for ETF holdings look-through.
+type HoldingsResponse struct {
+	Universe  string           `json:"universe"`
+	Sectors   []SectorHoldings `json:"sectors"`
+	Timestamp string           `json:"timestamp"`
+}
+
+// TechnicalsResponse is the JSON response for a sector's technical
+// indicators. Each indicator has one value per date, null until it has
+// enough history; Latest holds the final values.
+type TechnicalsResponse struct {
+	Universe   string                `json:"universe"`
+	Sector     string                `json:"sector"`
+	ETF        string                `json:"etf"`
+	Dates      []string              `json:"dates"`
+	Close      []float64             `json:"close"`
+	Indicators map[string][]*float64 `json:"indicators"`
+	Latest     map[string]*float64   `json:"latest"`
+	Timestamp  string                `json:"timestamp"`
+}
+
+// CorrelationPoint is the average pairwise sector correlation of the window
+// ending on one date.
+type CorrelationPoint struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// CorrelationsResponse is the JSON response for sector correlations.
+// Matrices are ordered as Sectors; covariance and volatility are annualized.
+// Clusters group sectors whose average correlation is at least
+// MinCorrelation, and Top is the highest-scoring sectors with their
+// diversification as an equal-weighted basket.
+type CorrelationsResponse struct {
+	Universe             string                  `json:"universe"`
+	Window               int                     `json:"window"`
+	Start                string                  `json:"start"`
+	End                  string                  `json:"end"`
+	Sectors              []string                `json:"sectors"`
+	Correlation          [][]float64             `json:"correlation"`
+	Covariance           [][]float64             `json:"covariance"`
+	Volatility           []float64               `json:"volatility"`
+	AverageCorrelation   float64                 `json:"average_correlation"`
+	DiversificationRatio float64                 `json:"diversification_ratio"`
+	MinCorrelation       float64                 `json:"min_correlation"`
+	Clusters             [][]string              `json:"clusters"`
+	Dendrogram           []analysis.ClusterMerge `json:"dendrogram"`
+	Top                  BasketDiversification   `json:"top"`
+	History              []CorrelationPoint      `json:"history"`
+	Timestamp            string                  `json:"timestamp"`
+}
+
+// BasketDiversification describes an equal-weighted basket of sectors.
+type BasketDiversification struct {
+	Sectors              []string `json:"sectors"`
+	AverageCorrelation   *float64 `json:"average_correlation"`
+	DiversificationRatio float64  `json:"diversification_ratio"`
+	Clusters             int      `json:"clusters"`
+}
+
+// PortfolioResponse is the response for target sector weights. Risk figures
+// are estimated from the Window sessions of returns from Start to End.
+type PortfolioResponse struct {
+	Universe string `json:"universe"`
+	portfolio.Allocation
+	Window    int    `json:"window"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Timestamp string `json:"timestamp"`
+}
+
+// RebalanceRequest is the body of a rebalance request. Current maps sector
+// names to portfolio weights, which may sum to less than one with the rest
+// in cash. NoTradeBand and Costs default to the configured values.
+type RebalanceRequest struct {
+	Current        map[string]float64  `json:"current"`
+	PortfolioValue float64             `json:"portfolio_value"`
+	NoTradeBand    float64             `json:"no_trade_band"`
+	Costs          portfolio.CostModel `json:"costs"`
+}
+
+// RebalanceResponse is the response for a rebalance proposal.
+type RebalanceResponse struct {
+	Universe       string              `json:"universe"`
+	Method         string              `json:"method"`
+	PortfolioValue float64             `json:"portfolio_value"`
+	NoTradeBand    float64             `json:"no_trade_band"`
+	Costs          portfolio.CostModel `json:"costs"`
+	portfolio.Proposal
+	Timestamp string `json:"timestamp"`
+}
+
+// ScenariosResponse lists what can be stressed and replayed, with the
+// estimated sector factor exposures.
+type ScenariosResponse struct {
+	Universe  string                        `json:"universe"`
+	Factors   []string                      `json:"factors"`
+	Presets   map[string]map[string]float64 `json:"presets"`
+	Windows   []config.HistoricalWindow     `json:"windows"`
+	Model     *scenario.Model               `json:"model"`
+	Timestamp string                        `json:"timestamp"`
+}
+
+// StressResponse is the response for a stress scenario. Exposures were
+// estimated over Months months from Start to End.
+type StressResponse struct {
+	Universe  string             `json:"universe"`
+	Scenario  string             `json:"scenario,omitempty"`
+	Shocks    map[string]float64 `json:"shocks"`
+	Propagate bool               `json:"propagate"`
+	Start     string             `json:"start"`
+	End       string             `json:"end"`
+	Months    int                `json:"months"`
+	scenario.Stress
+	Timestamp string `json:"timestamp"`
+}
+
+// ReplayResponse is the response for historical replays. Skipped maps
+// windows that could not be replayed to the reason.
+type ReplayResponse struct {
+	Universe  string            `json:"universe"`
+	Replays   []scenario.Replay `json:"replays"`
+	Skipped   map[string]string `json:"skipped,omitempty"`
+	Timestamp string            `json:"timestamp"`
+}
+
 // ErrorResponse is used for error responses.
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -73,20 +357,19 @@ type ErrorResponse struct {
 // ToSectorScoreResponse converts analysis.SectorScore to API response.
 func ToSectorScoreResponse(s analysis.SectorScore) SectorScoreResponse {
 	return SectorScoreResponse{
-		Sector:           s.Sector,
-		OpportunityScore: s.OpportunityScore,
-		Rank:             s.Rank,
-		MomentumScore:    s.MomentumScore,
-		ValuationScore:   s.ValuationScore,
-		GrowthScore:      s.GrowthScore,
-		InnovationScore:  s.InnovationScore,
-		MacroScore:       s.MacroScore,
-		PriceReturn3Mo:   s.PriceReturn3Mo,
-		PriceReturn6Mo:   s.PriceReturn6Mo,
-		PriceReturn12Mo:  s.PriceReturn12Mo,
-		RelativeStrength: s.RelativeStrength,
-		ForwardPE:        s.ForwardPE,
-		EmploymentGrowth: s.EmploymentGrowth,
-		RDIntensity:      s.RDIntensity,
+		Sector:            s.Sector,
+		OpportunityScore:  s.OpportunityScore,
+		Rank:              s.Rank,
+		Components:        s.Components,
+		FactorScore:       s.FactorScore,
+		CycleScore:        s.CycleScore,
+		PriceReturn3Mo:    s.PriceReturn3Mo,
+		PriceReturn6Mo:    s.PriceReturn6Mo,
+		PriceReturn12Mo:   s.PriceReturn12Mo,
+		RelativeStrength:  s.RelativeStrength,
+		ForwardPE:         s.ForwardPE,
+		EquityRiskPremium: s.EquityRiskPremium,
+		EmploymentGrowth:  s.EmploymentGrowth,
+		RDIntensity:       s.RDIntensity,
 	}
 }
@@ -0,0 +1,243 @@
+// Package calendar provides exchange trading calendars: which days are
+// sessions, holidays and early closes, and when each session opens and closes.
+//
+// Times passed to a Calendar are interpreted in its time zone, and returned
+// dates are midnight in that zone.
+//
+// Regular holidays are generated from rules, so any year is supported;
+// one-off closures (national days of mourning, weather) are embedded.
+package calendar
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	// Embedded zone data so calendars work without system tzdata
+	_ "time/tzdata"
+)
+
+// Day is a non-regular day in a calendar year.
+type Day struct {
+	Date       time.Time `json:"date"`
+	Name       string    `json:"name"`
+	EarlyClose bool      `json:"early_close"`
+}
+
+// schedule holds the holidays and early closes of one year, keyed by the
+// civil date at midnight UTC (see civil).
+type schedule struct {
+	holidays    map[time.Time]string
+	earlyCloses map[time.Time]string
+}
+
+// Calendar describes the trading sessions of one exchange.
+type Calendar struct {
+	Name     string
+	Location *time.Location
+
+	// Approximate calendars do not know the exchange's holidays, so a
+	// missing weekday may just be a closed market.
+	Approximate bool
+
+	open       time.Duration // since midnight, local time
+	close      time.Duration
+	earlyClose time.Duration
+
+	rules func(year int) schedule
+
+	mu    sync.Mutex
+	years map[int]schedule
+}
+
+// newCalendar creates a calendar with the given session hours and holiday rules.
+func newCalendar(name string, loc *time.Location, open, close, earlyClose time.Duration, rules func(int) schedule) *Calendar {
+	return &Calendar{
+		Name:       name,
+		Location:   loc,
+		open:       open,
+		close:      close,
+		earlyClose: earlyClose,
+		rules:      rules,
+		years:      make(map[int]schedule),
+	}
+}
+
+// Weekdays is a calendar with a session every Monday to Friday and no
+// holidays, used for markets without a dedicated calendar.
+var Weekdays = newApproximateCalendar()
+
+func newApproximateCalendar() *Calendar {
+	c := newCalendar("weekdays", time.UTC, 0, 24*time.Hour, 24*time.Hour, func(int) schedule {
+		return schedule{}
+	})
+	c.Approximate = true
+	return c
+}
+
+// Date returns midnight of t's date in the calendar's time zone.
+func (c *Calendar) Date(t time.Time) time.Time {
+	y, m, d := t.In(c.Location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.Location)
+}
+
+// civil returns t's date in the calendar's time zone as midnight UTC, the
+// key used by schedules.
+func (c *Calendar) civil(t time.Time) time.Time {
+	y, m, d := t.In(c.Location).Date()
+	return civilDate(y, m, d)
+}
+
+// civilDate builds a schedule key.
+func civilDate(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// local converts a schedule key to midnight in the calendar's time zone.
+func (c *Calendar) local(key time.Time) time.Time {
+	return time.Date(key.Year(), key.Month(), key.Day(), 0, 0, 0, 0, c.Location)
+}
+
+// year returns the schedule for a year, generating it on first use.
+func (c *Calendar) year(y int) schedule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.years[y]
+	if !ok {
+		s = c.rules(y)
+		c.years[y] = s
+	}
+	return s
+}
+
+// Holiday returns the name of the holiday on t's date, if the exchange is
+// closed for one. Weekends are not holidays.
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	d := c.civil(t)
+	name, ok := c.year(d.Year()).holidays[d]
+	return name, ok
+}
+
+// IsEarlyClose reports whether the session on t's date closes early.
+func (c *Calendar) IsEarlyClose(t time.Time) bool {
+	d := c.civil(t)
+	_, ok := c.year(d.Year()).earlyCloses[d]
+	return ok && c.IsTradingDay(t)
+}
+
+// IsTradingDay reports whether the exchange holds a session on t's date.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	d := c.civil(t)
+	if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := c.year(d.Year()).holidays[d]
+	return !holiday
+}
+
+// Session returns the open and close times of the session on t's date.
+// ok is false when the exchange is closed that day.
+func (c *Calendar) Session(t time.Time) (open, close time.Time, ok bool) {
+	if !c.IsTradingDay(t) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	midnight := c.Date(t)
+	open = midnight.Add(c.open)
+	close = midnight.Add(c.close)
+	if c.IsEarlyClose(t) {
+		close = midnight.Add(c.earlyClose)
+	}
+	return open, close, true
+}
+
+// NextTradingDay returns the date of the first session after t's date.
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	d := c.Date(t).AddDate(0, 0, 1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// PreviousTradingDay returns the date of the last session before t's date.
+func (c *Calendar) PreviousTradingDay(t time.Time) time.Time {
+	d := c.Date(t).AddDate(0, 0, -1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// LastTradingDay returns t's date if it is a session, else the previous session.
+func (c *Calendar) LastTradingDay(t time.Time) time.Time {
+	if c.IsTradingDay(t) {
+		return c.Date(t)
+	}
+	return c.PreviousTradingDay(t)
+}
+
+// AddTradingDays moves n sessions forward (or back when n is negative) from
+// t's date. A non-session date first snaps to the adjacent session in that
+// direction, which counts as the first step.
+func (c *Calendar) AddTradingDays(t time.Time, n int) time.Time {
+	d := c.Date(t)
+	for ; n > 0; n-- {
+		d = c.NextTradingDay(d)
+	}
+	for ; n < 0; n++ {
+		d = c.PreviousTradingDay(d)
+	}
+	return d
+}
+
+// TradingDaysBetween counts sessions strictly between the dates of from and to.
+func (c *Calendar) TradingDaysBetween(from, to time.Time) int {
+	count := 0
+	end := c.Date(to)
+	for d := c.Date(from).AddDate(0, 0, 1); d.Before(end); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			count++
+		}
+	}
+	return count
+}
+
+// TradingDays returns the session dates from from through to, inclusive.
+func (c *Calendar) TradingDays(from, to time.Time) []time.Time {
+	var days []time.Time
+	end := c.Date(to)
+	for d := c.Date(from); !d.After(end); d = d.AddDate(0, 0, 1) {
+		if c.IsTradingDay(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// NextClose returns the first session close strictly after t.
+func (c *Calendar) NextClose(t time.Time) time.Time {
+	if _, close, ok := c.Session(t); ok && close.After(t) {
+		return close
+	}
+	_, close, _ := c.Session(c.NextTradingDay(t))
+	return close
+}
+
+// Holidays returns the holidays and early closes of a year, in date order.
+func (c *Calendar) Holidays(year int) []Day {
+	s := c.year(year)
+
+	days := make([]Day, 0, len(s.holidays)+len(s.earlyCloses))
+	for d, name := range s.holidays {
+		days = append(days, Day{Date: c.local(d), Name: name})
+	}
+	for d, name := range s.earlyCloses {
+		if _, closed := s.holidays[d]; !closed {
+			days = append(days, Day{Date: c.local(d), Name: name, EarlyClose: true})
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days
+}
@@ -0,0 +1,157 @@
+// The New York Stock Exchange calendar.
+
+package calendar
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+//go:embed nyse_closures.json
+var nyseClosuresJSON []byte
+
+// specialDay is an embedded one-off closure or early close.
+type specialDay struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// specialDays lists unscheduled closures and early closes by date.
+type specialDays struct {
+	Closures    []specialDay `json:"closures"`
+	EarlyCloses []specialDay `json:"early_closes"`
+}
+
+// NYSE is the New York Stock Exchange calendar: sessions from 9:30 to 16:00
+// Eastern, with 13:00 early closes around Independence Day, Thanksgiving and
+// Christmas.
+var NYSE = newNYSE()
+
+func newNYSE() *Calendar {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		panic(fmt.Sprintf("calendar: %v", err))
+	}
+
+	var special specialDays
+	if err := json.Unmarshal(nyseClosuresJSON, &special); err != nil {
+		panic(fmt.Sprintf("calendar: invalid nyse_closures.json: %v", err))
+	}
+	closures := parseSpecialDays(special.Closures)
+	earlyCloses := parseSpecialDays(special.EarlyCloses)
+
+	return newCalendar("NYSE", loc,
+		9*time.Hour+30*time.Minute, 16*time.Hour, 13*time.Hour,
+		func(year int) schedule {
+			s := nyseSchedule(year)
+			for d, name := range closures {
+				if d.Year() == year {
+					s.holidays[d] = name
+				}
+			}
+			for d, name := range earlyCloses {
+				if d.Year() == year {
+					s.earlyCloses[d] = name
+				}
+			}
+			return s
+		})
+}
+
+// parseSpecialDays keys embedded days by date, panicking on malformed data.
+func parseSpecialDays(days []specialDay) map[time.Time]string {
+	parsed := make(map[time.Time]string, len(days))
+	for _, day := range days {
+		d, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			panic(fmt.Sprintf("calendar: invalid date %q: %v", day.Date, err))
+		}
+		parsed[d] = day.Name
+	}
+	return parsed
+}
+
+// nyseSchedule generates the regular NYSE holidays and early closes for a year.
+func nyseSchedule(year int) schedule {
+	s := schedule{
+		holidays:    make(map[time.Time]string),
+		earlyCloses: make(map[time.Time]string),
+	}
+
+	// New Year's Day falling on a Saturday is not observed on the Friday before
+	if newYear := civilDate(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		s.holidays[observed(newYear)] = "New Year's Day"
+	}
+	s.holidays[nthWeekday(year, time.January, time.Monday, 3)] = "Martin Luther King Jr. Day"
+	s.holidays[nthWeekday(year, time.February, time.Monday, 3)] = "Washington's Birthday"
+	s.holidays[easter(year).AddDate(0, 0, -2)] = "Good Friday"
+	s.holidays[lastWeekday(year, time.May, time.Monday)] = "Memorial Day"
+	if year >= 2022 {
+		s.holidays[observed(civilDate(year, time.June, 19))] = "Juneteenth"
+	}
+	s.holidays[observed(civilDate(year, time.July, 4))] = "Independence Day"
+	s.holidays[nthWeekday(year, time.September, time.Monday, 1)] = "Labor Day"
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	s.holidays[thanksgiving] = "Thanksgiving Day"
+	s.holidays[observed(civilDate(year, time.December, 25))] = "Christmas Day"
+
+	// Early closes: the eves of Independence Day and Christmas when both days
+	// are sessions, and the day after Thanksgiving
+	if july3 := civilDate(year, time.July, 3); isWeekday(july3) && isWeekday(july3.AddDate(0, 0, 1)) {
+		s.earlyCloses[july3] = "Independence Day (eve)"
+	}
+	s.earlyCloses[thanksgiving.AddDate(0, 0, 1)] = "Day after Thanksgiving"
+	if dec24 := civilDate(year, time.December, 24); isWeekday(dec24) && isWeekday(dec24.AddDate(0, 0, 1)) {
+		s.earlyCloses[dec24] = "Christmas Eve"
+	}
+
+	return s
+}
+
+// observed moves a Saturday holiday to Friday and a Sunday holiday to Monday.
+func observed(d time.Time) time.Time {
+	switch d.Weekday() {
+	case time.Saturday:
+		return d.AddDate(0, 0, -1)
+	case time.Sunday:
+		return d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// isWeekday reports whether d falls Monday to Friday.
+func isWeekday(d time.Time) bool {
+	return d.Weekday() != time.Saturday && d.Weekday() != time.Sunday
+}
+
+// nthWeekday returns the nth occurrence of a weekday in a month.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	d := civilDate(year, month, 1)
+	offset := (int(weekday) - int(d.Weekday()) + 7) % 7
+	return d.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last occurrence of a weekday in a month.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	d := civilDate(year, month+1, 0)
+	offset := (int(d.Weekday()) - int(weekday) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// easter returns Western Easter Sunday (anonymous Gregorian algorithm).
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return civilDate(year, time.Month(month), day)
+}
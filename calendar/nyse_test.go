@@ -0,0 +1,145 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+// nyseDay parses a date at noon in New York.
+func nyseDay(t *testing.T, date string) time.Time {
+	t.Helper()
+	d, err := time.ParseInLocation("2006-01-02", date, NYSE.Location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.Add(12 * time.Hour)
+}
+
+func TestEaster(t *testing.T) {
+	tests := []struct {
+		year int
+		want string
+	}{
+		{2000, "2000-04-23"},
+		{2019, "2019-04-21"},
+		{2024, "2024-03-31"},
+		{2025, "2025-04-20"},
+		{2038, "2038-04-25"},
+	}
+	for _, tt := range tests {
+		if got := easter(tt.year).Format("2006-01-02"); got != tt.want {
+			t.Errorf("easter(%d) = %s, want %s", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestNYSEHolidays(t *testing.T) {
+	tests := []struct {
+		date    string
+		holiday string // "" when the exchange is open
+	}{
+		// Good Friday
+		{"2019-04-19", "Good Friday"},
+		{"2024-03-29", "Good Friday"},
+		{"2025-04-18", "Good Friday"},
+		{"2024-03-28", ""},
+
+		// Saturday holidays move to Friday, Sunday ones to Monday
+		{"2026-07-03", "Independence Day"},
+		{"2022-12-26", "Christmas Day"},
+		{"2023-01-02", "New Year's Day"},
+		{"2021-12-31", ""}, // New Year's Day 2022 fell on a Saturday
+
+		// Juneteenth from 2022
+		{"2021-06-18", ""},
+		{"2022-06-20", "Juneteenth"},
+		{"2023-06-19", "Juneteenth"},
+		{"2027-06-18", "Juneteenth"},
+
+		// Rule-based holidays
+		{"2024-01-15", "Martin Luther King Jr. Day"},
+		{"2024-05-27", "Memorial Day"},
+		{"2024-11-28", "Thanksgiving Day"},
+
+		// Embedded one-off closures
+		{"2012-10-29", "Hurricane Sandy"},
+		{"2025-01-09", "National Day of Mourning for Jimmy Carter"},
+	}
+	for _, tt := range tests {
+		d := nyseDay(t, tt.date)
+		name, ok := NYSE.Holiday(d)
+		if tt.holiday == "" {
+			if ok {
+				t.Errorf("%s: unexpected holiday %q", tt.date, name)
+			}
+			if !NYSE.IsTradingDay(d) {
+				t.Errorf("%s: not a trading day", tt.date)
+			}
+			continue
+		}
+		if name != tt.holiday {
+			t.Errorf("%s: holiday = %q, want %q", tt.date, name, tt.holiday)
+		}
+		if NYSE.IsTradingDay(d) {
+			t.Errorf("%s: is a trading day", tt.date)
+		}
+	}
+}
+
+func TestNYSEEarlyCloses(t *testing.T) {
+	tests := []struct {
+		date  string
+		early bool
+	}{
+		{"2024-07-03", true},
+		{"2024-11-29", true},
+		{"2024-12-24", true},
+		{"2019-07-03", true},
+		{"2024-12-23", false},
+		{"2026-07-02", false}, // July 3 is the observed holiday
+		{"2022-12-23", false}, // Christmas Eve fell on a Saturday
+		{"2020-07-02", false}, // Independence Day was observed on July 3
+	}
+	for _, tt := range tests {
+		d := nyseDay(t, tt.date)
+		if got := NYSE.IsEarlyClose(d); got != tt.early {
+			t.Errorf("%s: IsEarlyClose = %v, want %v", tt.date, got, tt.early)
+		}
+
+		open, close, ok := NYSE.Session(d)
+		if !ok {
+			t.Errorf("%s: no session", tt.date)
+			continue
+		}
+		wantClose := "16:00"
+		if tt.early {
+			wantClose = "13:00"
+		}
+		if got := open.Format("15:04"); got != "09:30" {
+			t.Errorf("%s: open = %s, want 09:30", tt.date, got)
+		}
+		if got := close.Format("15:04"); got != wantClose {
+			t.Errorf("%s: close = %s, want %s", tt.date, got, wantClose)
+		}
+	}
+}
+
+func TestNYSETradingDays(t *testing.T) {
+	tests := []struct {
+		from, to string
+		between  int
+	}{
+		{"2024-03-27", "2024-04-02", 2}, // Good Friday and a weekend
+		{"2024-12-20", "2024-12-27", 3}, // Christmas Day
+		{"2024-06-03", "2024-06-04", 0},
+	}
+	for _, tt := range tests {
+		if got := NYSE.TradingDaysBetween(nyseDay(t, tt.from), nyseDay(t, tt.to)); got != tt.between {
+			t.Errorf("TradingDaysBetween(%s, %s) = %d, want %d", tt.from, tt.to, got, tt.between)
+		}
+	}
+
+	if got := NYSE.LastTradingDay(nyseDay(t, "2024-03-31")).Format("2006-01-02"); got != "2024-03-28" {
+		t.Errorf("LastTradingDay(2024-03-31) = %s, want 2024-03-28", got)
+	}
+}
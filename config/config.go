@@ -1,7 +1,17 @@
 // Package config contains all configuration constants and mappings.
+//
+// The sector mappings below are the built-in defaults. They can be replaced
+// at startup with a YAML or JSON file named by SECTOR_CONFIG (see sectors.go).
 package config
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
 
 // SectorETFs maps GICS sector names to their SPDR ETF tickers.
 var SectorETFs = map[string]string{
@@ -34,20 +44,296 @@ var SectorNames = []string{
 }
 
 // MarketBenchmark is the S&P 500 ETF for relative strength calculations.
-const MarketBenchmark = "SPY"
+var MarketBenchmark = "SPY"
 
 // CacheDuration is how long cached data remains valid.
 const CacheDuration = 12 * time.Hour
 
+// DatabasePath is the SQLite database holding fetched histories and score
+// snapshots. Override with DATABASE_PATH.
+var DatabasePath = envOr("DATABASE_PATH", "sector-analyzer.db")
+
+// RevisionWindow is how many of the most recent stored bars or observations
+// are re-requested on each update, to pick up late revisions.
+const RevisionWindow = 5
+
 // DefaultWeights for scoring categories.
 var DefaultWeights = map[string]float64{
 	"momentum":   0.25,
+	"breadth":    0,
 	"valuation":  0.20,
 	"growth":     0.20,
 	"innovation": 0.20,
+	"quality":    0,
 	"macro":      0.15,
 }
 
+// ValuationMethod selects the valuation signal: "relative" ranks forward P/E
+// across sectors, "erp" scores each sector's earnings yield over the 10-year
+// Treasury against ERPAnchor, and "blend" averages the two. Set with
+// VALUATION_METHOD.
+var ValuationMethod = envOr("VALUATION_METHOD", "relative")
+
+// ValuationMethods lists the accepted ValuationMethod values.
+var ValuationMethods = []string{"relative", "erp", "blend"}
+
+// ScoringModel selects how opportunity scores are formed: "factor" weights
+// the signal components, "cycle" ranks sectors by their performance in the
+// current business-cycle phase, and "blend" averages the two. Set with
+// SCORING_MODEL.
+var ScoringModel = envOr("SCORING_MODEL", "factor")
+
+// ScoringModels lists the accepted ScoringModel values.
+var ScoringModels = []string{"factor", "cycle", "blend"}
+
+// ERPAnchor is the long-run equity risk premium over the 10-year Treasury
+// that scores 50 under the "erp" valuation method; each ERPScale above or
+// below it moves the score by 50 points.
+const (
+	ERPAnchor = 0.03
+	ERPScale  = 0.03
+)
+
+// HoldingsDir, when set, is a directory of ETF holdings exports named after
+// the ETF (XLK.csv) and an optional fundamentals.csv of per-constituent
+// forward P/E, earnings growth and R&D intensity. Set with HOLDINGS_DIR.
+var HoldingsDir = os.Getenv("HOLDINGS_DIR")
+
+// FetchConstituentFundamentals requests fundamentals missing from
+// fundamentals.csv from Yahoo, one request per constituent. Set
+// HOLDINGS_FETCH_FUNDAMENTALS=true to enable.
+var FetchConstituentFundamentals = os.Getenv("HOLDINGS_FETCH_FUNDAMENTALS") == "true"
+
+// MomentumIndicators lists technical indicators added to the momentum signal
+// as sub-signals, from MomentumIndicatorNames. Set MOMENTUM_INDICATORS to a
+// comma-separated list such as "rsi,trend".
+var MomentumIndicators = envList("MOMENTUM_INDICATORS")
+
+// MomentumIndicatorNames lists the accepted MomentumIndicators values.
+var MomentumIndicatorNames = []string{"rsi", "macd", "roc", "trend", "bollinger"}
+
+// CheckMomentumIndicators returns names with duplicates removed, or an
+// error if any is not in MomentumIndicatorNames.
+func CheckMomentumIndicators(names []string) ([]string, error) {
+	var indicators []string
+	for _, name := range names {
+		if !slices.Contains(MomentumIndicatorNames, name) {
+			return nil, fmt.Errorf("unknown momentum indicator %q (available: %s)",
+				name, strings.Join(MomentumIndicatorNames, ", "))
+		}
+		if !slices.Contains(indicators, name) {
+			indicators = append(indicators, name)
+		}
+	}
+	return indicators, nil
+}
+
+// IndicatorWeight is the share of the momentum score given to the selected
+// indicators, split equally between them.
+const IndicatorWeight = 0.25
+
+// CorrelationWindow is the default number of sessions of daily returns used
+// for sector correlations, and ClusterCorrelation the average correlation at
+// which sectors are grouped into one cluster.
+const (
+	CorrelationWindow  = 126
+	ClusterCorrelation = 0.7
+)
+
+// PortfolioMethods lists the portfolio construction methods of /api/portfolio.
+var PortfolioMethods = []string{"top_n", "score", "inverse_vol", "risk_parity", "mean_variance"}
+
+// Portfolio construction defaults. PortfolioTopN is how many sectors "top_n"
+// and "score" hold; RiskAversion is the mean-variance trade-off; ScoreIC is
+// the assumed information coefficient of the opportunity score, which scales
+// scores into expected returns.
+const (
+	PortfolioTopN = 3
+	RiskAversion  = 2.0
+	ScoreIC       = 0.05
+)
+
+// Rebalancing cost defaults. SpreadBps is half the bid-ask spread of a
+// sector ETF and CommissionBps the commission rate, both in basis points of
+// trade value; MinCommission is the smallest commission charged per trade.
+// Weight changes no larger than NoTradeBand are not traded.
+const (
+	SpreadBps     = 1.0
+	CommissionBps = 0.5
+	MinCommission = 1.0
+	NoTradeBand   = 0.0
+)
+
+// ScenarioFactors lists the factors scenarios can shock: the benchmark's
+// return (market, -0.2 is a 20% fall), and changes in the 10-year Treasury
+// yield, the 10y-2y spread and year-over-year CPI inflation, in percentage
+// points (treasury_10y 1 is +100bp).
+var ScenarioFactors = []string{"market", "treasury_10y", "yield_curve", "inflation"}
+
+// ScenarioPresets are named factor shocks for /api/scenarios/stress.
+var ScenarioPresets = map[string]map[string]float64{
+	"rate_shock":      {"treasury_10y": 1.0},
+	"inflation_shock": {"inflation": 1.0},
+	"equity_selloff":  {"market": -0.20},
+	"stagflation":     {"inflation": 2.0, "market": -0.15},
+	"bull_steepener":  {"treasury_10y": -0.5, "yield_curve": 0.75},
+}
+
+// HistoricalWindow is a named period replayed by /api/scenarios/replay.
+// From and To are YYYY-MM-DD dates.
+type HistoricalWindow struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+// HistoricalWindows are the named periods available for replay.
+var HistoricalWindows = []HistoricalWindow{
+	{"covid_crash", "COVID-19 crash, S&P 500 peak to trough", "2020-02-19", "2020-03-23"},
+	{"rate_shock_2022", "2022 rate shock, S&P 500 peak to October low", "2022-01-03", "2022-10-12"},
+	{"regional_banks_2023", "Silicon Valley Bank failure and regional bank stress", "2023-03-08", "2023-03-17"},
+	{"rate_peak_2023", "10-year yield climb to 5% and equity pullback", "2023-07-31", "2023-10-27"},
+	{"carry_unwind_2024", "Yen carry trade unwind", "2024-07-16", "2024-08-05"},
+	{"tariff_shock_2025", "April 2025 tariff announcement selloff", "2025-04-02", "2025-04-08"},
+}
+
+// Scenario model defaults. ScenarioMonths is how many months of history the
+// factor regressions use; ScenarioMinMonths is the fewest, beyond one per
+// factor, they need.
+const (
+	ScenarioMonths    = 60
+	ScenarioMinMonths = 24
+)
+
+// CyclePhases lists the business-cycle phases of the cycle model.
+var CyclePhases = []string{"early", "mid", "late", "recession"}
+
+// CycleTilt lists the sectors that have typically led and lagged in a phase.
+type CycleTilt struct {
+	Favored   []string
+	Unfavored []string
+}
+
+// CycleTextbook holds the conventional sector rotation through the business
+// cycle, used for a phase the stored history has too few months of.
+var CycleTextbook = map[string]CycleTilt{
+	"early": {
+		Favored:   []string{"Consumer Discretionary", "Financials", "Real Estate", "Industrials", "Information Technology", "Materials"},
+		Unfavored: []string{"Consumer Staples", "Health Care", "Utilities", "Energy"},
+	},
+	"mid": {
+		Favored:   []string{"Information Technology", "Communication Services", "Industrials"},
+		Unfavored: []string{"Materials", "Utilities"},
+	},
+	"late": {
+		Favored:   []string{"Energy", "Materials", "Consumer Staples", "Health Care", "Utilities"},
+		Unfavored: []string{"Information Technology", "Consumer Discretionary"},
+	},
+	"recession": {
+		Favored:   []string{"Consumer Staples", "Utilities", "Health Care"},
+		Unfavored: []string{"Industrials", "Information Technology", "Real Estate"},
+	},
+}
+
+// Cycle model thresholds. Activity growing slower than CycleTrendGrowth
+// (industrial production, % year over year) is below trend; a 10y-2y spread
+// under CycleFlatCurve (points) is flat; an unemployment gap of CycleSahmGap
+// (points) signals recession. A phase needs CycleMinMonths months of history
+// to be scored from it rather than from CycleTextbook. A month's readings are
+// credited with returns CycleReleaseLag months later: industrial production
+// and CPI for a month are published in the middle of the next, so the month
+// after that is the first one traded entirely on them.
+const (
+	CycleTrendGrowth = 2.0
+	CycleFlatCurve   = 0.5
+	CycleSahmGap     = 0.5
+	CycleMinMonths   = 6
+	CycleReleaseLag  = 2
+)
+
+// BreadthConstituents is how many of each sector's largest holdings have
+// their prices fetched for the breadth signal.
+const BreadthConstituents = 50
+
+// FundamentalsSource selects where valuation, quality and innovation inputs
+// come from: "etf" uses the ETF's own quote data and Damodaran industries,
+// "holdings" prefers metrics aggregated from the ETF's holdings and falls
+// back to the ETF data. Set with FUNDAMENTALS_SOURCE.
+var FundamentalsSource = envOr("FUNDAMENTALS_SOURCE", "etf")
+
+// FundamentalsSources lists the accepted FundamentalsSource values.
+var FundamentalsSources = []string{"etf", "holdings"}
+
+// UseTotalReturn makes return-based signals use dividend- and split-adjusted
+// closes. Set RETURN_BASIS=price to score on price return only.
+var UseTotalReturn = os.Getenv("RETURN_BASIS") != "price"
+
+// Price validation thresholds. A daily move larger than MaxDailyMove is
+// implausible for a sector ETF; runs of identical closes or zero volume at
+// least this long are reported. Every missing NYSE session is reported, but
+// markets without an exchange calendar only report gaps of
+// MaxMissingTradingDays weekdays or more.
+const (
+	MaxDailyMove          = 0.25
+	StaleCloseRun         = 5
+	ZeroVolumeRun         = 5
+	MaxMissingTradingDays = 3
+)
+
+// ValidateOptions checks the scoring options read from the environment and
+// removes duplicate momentum indicators. It must be called at startup,
+// before any scores are computed.
+func ValidateOptions() error {
+	var errs []error
+
+	indicators, err := CheckMomentumIndicators(MomentumIndicators)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid MOMENTUM_INDICATORS: %w", err))
+	}
+	MomentumIndicators = indicators
+
+	if err := checkOption("VALUATION_METHOD", ValuationMethod, ValuationMethods); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkOption("FUNDAMENTALS_SOURCE", FundamentalsSource, FundamentalsSources); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkOption("SCORING_MODEL", ScoringModel, ScoringModels); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkOption reports an error if the environment variable key's value is
+// not one of allowed.
+func checkOption(key, value string, allowed []string) error {
+	if !slices.Contains(allowed, value) {
+		return fmt.Errorf("invalid %s %q: must be one of: %s", key, value, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// envOr returns the environment variable key, or fallback when it is unset.
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// envList splits a comma-separated environment variable, dropping blanks.
+func envList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // MomentumPeriods in months for return calculations.
 var MomentumPeriods = []int{3, 6, 12}
 
@@ -74,18 +360,72 @@ var BLSEmploymentSeries = map[string]string{
 
 // FREDSeries maps names to FRED series identifiers.
 var FREDSeries = map[string]string{
-	"treasury_10y": "DGS10",
-	"treasury_2y":  "DGS2",
-	"fed_funds":    "FEDFUNDS",
-	"cpi":          "CPIAUCSL",
-	"core_cpi":     "CPILFESL",
-	"gdp":          "GDP",
+	"treasury_10y":          "DGS10",
+	"treasury_2y":           "DGS2",
+	"fed_funds":             "FEDFUNDS",
+	"cpi":                   "CPIAUCSL",
+	"core_cpi":              "CPILFESL",
+	"gdp":                   "GDP",
+	"unemployment":          "UNRATE",
+	"industrial_production": "INDPRO",
+}
+
+// BaseCurrency is the currency FRED exchange rates are quoted against.
+const BaseCurrency = "USD"
+
+// FXRateSeries identifies a FRED daily exchange rate series. USDPerUnit is
+// true when the series is quoted as USD per unit of the foreign currency
+// (e.g. DEXUSEU) and false when quoted as foreign units per USD (DEXJPUS).
+type FXRateSeries struct {
+	SeriesID   string
+	USDPerUnit bool
+}
+
+// FXSeries maps ISO currency codes to FRED exchange rate series.
+var FXSeries = map[string]FXRateSeries{
+	"EUR": {SeriesID: "DEXUSEU", USDPerUnit: true},
+	"GBP": {SeriesID: "DEXUSUK", USDPerUnit: true},
+	"AUD": {SeriesID: "DEXUSAL", USDPerUnit: true},
+	"NZD": {SeriesID: "DEXUSNZ", USDPerUnit: true},
+	"JPY": {SeriesID: "DEXJPUS", USDPerUnit: false},
+	"CAD": {SeriesID: "DEXCAUS", USDPerUnit: false},
+	"CHF": {SeriesID: "DEXSZUS", USDPerUnit: false},
+	"SEK": {SeriesID: "DEXSDUS", USDPerUnit: false},
+	"NOK": {SeriesID: "DEXNOUS", USDPerUnit: false},
+	"DKK": {SeriesID: "DEXDNUS", USDPerUnit: false},
+	"CNY": {SeriesID: "DEXCHUS", USDPerUnit: false},
+	"HKD": {SeriesID: "DEXHKUS", USDPerUnit: false},
+	"KRW": {SeriesID: "DEXKOUS", USDPerUnit: false},
+	"TWD": {SeriesID: "DEXTAUS", USDPerUnit: false},
+	"INR": {SeriesID: "DEXINUS", USDPerUnit: false},
+	"SGD": {SeriesID: "DEXSIUS", USDPerUnit: false},
+	"BRL": {SeriesID: "DEXBZUS", USDPerUnit: false},
+	"MXN": {SeriesID: "DEXMXUS", USDPerUnit: false},
+	"ZAR": {SeriesID: "DEXSFUS", USDPerUnit: false},
 }
 
 // DamodaranRDURL is the URL for R&D intensity data.
 const DamodaranRDURL = "https://pages.stern.nyu.edu/~adamodar/pc/datasets/R&D.xls"
 
-// DamodaranToGICS maps Damodaran industry names to GICS sectors.
+// DamodaranRDPath, when set, is a local .xls or .xlsx copy of the R&D
+// dataset that is read instead of downloading it. Set with DAMODARAN_RD_PATH.
+var DamodaranRDPath = os.Getenv("DAMODARAN_RD_PATH")
+
+// DamodaranArchiveURL is the URL pattern of Damodaran's yearly R&D archives,
+// formatted with the two-digit year of publication.
+const DamodaranArchiveURL = "https://pages.stern.nyu.edu/~adamodar/pc/archives/R&D%02d.xls"
+
+// DamodaranArchiveDir, when set, is a directory of archived datasets named
+// like R&D19.xls or R&D2019.xlsx; every archive in it is ingested instead of
+// downloading the last DamodaranArchiveYears. Set with DAMODARAN_ARCHIVE_DIR.
+var DamodaranArchiveDir = os.Getenv("DAMODARAN_ARCHIVE_DIR")
+
+// DamodaranArchiveYears is how many years of archives are downloaded to build
+// the R&D intensity history.
+const DamodaranArchiveYears = 5
+
+// DamodaranToGICS maps Damodaran industry names to GICS sectors. A sector
+// config replaces it with the default universe's damodaran_industries.
 var DamodaranToGICS = map[string]string{
 	// Information Technology
 	"Software (System & Application)": "Information Technology",
@@ -168,3 +508,46 @@ var DamodaranToGICS = map[string]string{
 	"Publishing & Newspapers":   "Communication Services",
 	"Advertising":               "Communication Services",
 }
+
+// IndustryGroupUniverse returns the built-in universe of GICS industry group
+// and sub-industry ETFs, scored against the S&P 500.
+func IndustryGroupUniverse() SectorUniverse {
+	return SectorUniverse{
+		Name:        "industries",
+		Description: "GICS industry groups and sub-industries (industry ETFs)",
+		Benchmark:   "SPY",
+		Currency:    BaseCurrency,
+		Sectors: []SectorDefinition{
+			{Name: "Semiconductors", ETF: "SMH", BLSSeries: "CES3133440001",
+				DamodaranIndustries: []string{"Semiconductor", "Semiconductor Equip"}},
+			{Name: "Software", ETF: "IGV",
+				DamodaranIndustries: []string{"Software (System & Application)", "Software (Internet)", "Software (Entertainment)"}},
+			{Name: "Regional Banks", ETF: "KRE",
+				DamodaranIndustries: []string{"Banks (Regional)"}},
+			{Name: "Capital Markets", ETF: "KCE", BLSSeries: "CES5552300001",
+				DamodaranIndustries: []string{"Brokerage & Investment Banking"}},
+			{Name: "Insurance", ETF: "KIE", BLSSeries: "CES5552400001",
+				DamodaranIndustries: []string{"Insurance (General)", "Insurance (Life)", "Insurance (Prop/Cas.)"}},
+			{Name: "Biotechnology", ETF: "XBI",
+				DamodaranIndustries: []string{"Drugs (Biotechnology)"}},
+			{Name: "Pharmaceuticals", ETF: "XPH", BLSSeries: "CES3232540001",
+				DamodaranIndustries: []string{"Drugs (Pharmaceutical)"}},
+			{Name: "Health Care Equipment", ETF: "XHE",
+				DamodaranIndustries: []string{"Healthcare Products", "Medical Supplies"}},
+			{Name: "Homebuilders", ETF: "ITB", BLSSeries: "CES2023600001",
+				DamodaranIndustries: []string{"Homebuilding", "Building Materials"}},
+			{Name: "Retail", ETF: "XRT", BLSSeries: "CES4200000001",
+				DamodaranIndustries: []string{"Retail (General)", "Retail (Online)", "Retail (Special Lines)"}},
+			{Name: "Oil & Gas Exploration & Production", ETF: "XOP", BLSSeries: "CES1021100001",
+				DamodaranIndustries: []string{"Oil/Gas (Production and Exploration)"}},
+			{Name: "Oil & Gas Equipment & Services", ETF: "XES", BLSSeries: "CES1021300001",
+				DamodaranIndustries: []string{"Oilfield Svcs/Equip."}},
+			{Name: "Aerospace & Defense", ETF: "XAR", BLSSeries: "CES3133640001",
+				DamodaranIndustries: []string{"Aerospace/Defense"}},
+			{Name: "Transportation", ETF: "XTN", BLSSeries: "CES4300000001",
+				DamodaranIndustries: []string{"Transportation", "Trucking", "Air Transport"}},
+			{Name: "Metals & Mining", ETF: "XME", BLSSeries: "CES1021200001",
+				DamodaranIndustries: []string{"Metals & Mining", "Steel"}},
+		},
+	}
+}
@@ -0,0 +1,408 @@
+// Sector universes loaded from a YAML or JSON file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SectorConfigEnv names the environment variable pointing at a sector config file.
+const SectorConfigEnv = "SECTOR_CONFIG"
+
+// DefaultUniverseName is the universe scored when a request doesn't name one.
+var DefaultUniverseName = "sectors"
+
+// SectorDefinition describes one sector and the data sources mapped to it.
+type SectorDefinition struct {
+	Name                string   `yaml:"name" json:"name"`
+	ETF                 string   `yaml:"etf" json:"etf"`
+	Currency            string   `yaml:"currency,omitempty" json:"currency,omitempty"`
+	BLSSeries           string   `yaml:"bls_series,omitempty" json:"bls_series,omitempty"`
+	DamodaranIndustries []string `yaml:"damodaran_industries,omitempty" json:"damodaran_industries,omitempty"`
+}
+
+// SectorUniverse is a named set of sectors scored against a common benchmark.
+//
+// Currency is the quote currency of the benchmark and of any sector that
+// doesn't set its own. When ConvertTo is set, every price series is
+// converted into that currency before returns are calculated.
+type SectorUniverse struct {
+	Name        string             `yaml:"name" json:"name"`
+	Description string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Benchmark   string             `yaml:"benchmark" json:"benchmark"`
+	Currency    string             `yaml:"currency,omitempty" json:"currency,omitempty"`
+	ConvertTo   string             `yaml:"convert_to,omitempty" json:"convert_to,omitempty"`
+	Sectors     []SectorDefinition `yaml:"sectors" json:"sectors"`
+}
+
+// SectorNames returns the universe's sector names in definition order.
+func (u SectorUniverse) SectorNames() []string {
+	names := make([]string, 0, len(u.Sectors))
+	for _, s := range u.Sectors {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// SectorCurrency returns the quote currency of a sector's ETF.
+func (u SectorUniverse) SectorCurrency(s SectorDefinition) string {
+	if s.Currency != "" {
+		return s.Currency
+	}
+	return u.Currency
+}
+
+// NeedsConversion reports whether any series must be converted to ConvertTo.
+func (u SectorUniverse) NeedsConversion() bool {
+	if u.ConvertTo == "" {
+		return false
+	}
+	if u.Currency != u.ConvertTo {
+		return true
+	}
+	for _, s := range u.Sectors {
+		if u.SectorCurrency(s) != u.ConvertTo {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceCurrency returns the currency scored prices are expressed in.
+func (u SectorUniverse) PriceCurrency() string {
+	if u.ConvertTo != "" {
+		return u.ConvertTo
+	}
+	return u.Currency
+}
+
+// Sector returns the definition for a sector name.
+func (u SectorUniverse) Sector(name string) (SectorDefinition, bool) {
+	for _, s := range u.Sectors {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return SectorDefinition{}, false
+}
+
+// UniverseConfig is the on-disk format of a sector config file.
+//
+// A file either lists named universes, or - as a shorthand for replacing
+// only the default universe - gives benchmark and sectors at the top level.
+type UniverseConfig struct {
+	Default   string             `yaml:"default,omitempty" json:"default,omitempty"`
+	Universes []SectorUniverse   `yaml:"universes,omitempty" json:"universes,omitempty"`
+	Benchmark string             `yaml:"benchmark,omitempty" json:"benchmark,omitempty"`
+	Sectors   []SectorDefinition `yaml:"sectors,omitempty" json:"sectors,omitempty"`
+}
+
+// Universes holds every configured universe by name.
+var Universes = map[string]SectorUniverse{
+	"sectors":    DefaultSectorUniverse(),
+	"industries": IndustryGroupUniverse(),
+}
+
+// gicsIndustries lists the Damodaran industries of each GICS sector, taken
+// from the built-in DamodaranToGICS before a sector config can replace it.
+var gicsIndustries = invertDamodaranMapping(DamodaranToGICS)
+
+// DefaultSectorUniverse returns the built-in SPDR sector universe.
+func DefaultSectorUniverse() SectorUniverse {
+	u := SectorUniverse{
+		Name:        "sectors",
+		Description: "GICS sectors (SPDR Select Sector ETFs)",
+		Benchmark:   MarketBenchmark,
+		Currency:    BaseCurrency,
+	}
+	for _, name := range SectorNames {
+		u.Sectors = append(u.Sectors, SectorDefinition{
+			Name:                name,
+			ETF:                 SectorETFs[name],
+			BLSSeries:           BLSEmploymentSeries[name],
+			DamodaranIndustries: gicsIndustries[name],
+		})
+	}
+	return u
+}
+
+// DefaultUniverse returns the universe used when none is requested.
+func DefaultUniverse() SectorUniverse {
+	if u, ok := Universes[DefaultUniverseName]; ok {
+		return u
+	}
+	return DefaultSectorUniverse()
+}
+
+// LookupUniverse finds a universe by name (case-insensitive). An empty name
+// selects the default universe.
+func LookupUniverse(name string) (SectorUniverse, bool) {
+	if name == "" {
+		return DefaultUniverse(), true
+	}
+	if u, ok := Universes[name]; ok {
+		return u, true
+	}
+	for key, u := range Universes {
+		if strings.EqualFold(key, name) {
+			return u, true
+		}
+	}
+	return SectorUniverse{}, false
+}
+
+// UniverseNames returns all configured universe names, default first.
+func UniverseNames() []string {
+	names := make([]string, 0, len(Universes))
+	for name := range Universes {
+		if name != DefaultUniverseName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := Universes[DefaultUniverseName]; ok {
+		names = append([]string{DefaultUniverseName}, names...)
+	}
+	return names
+}
+
+// LoadUniverseConfig reads and validates a sector config file.
+// JSON files are accepted as well, since JSON is valid YAML.
+func LoadUniverseConfig(path string) (UniverseConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return UniverseConfig{}, fmt.Errorf("failed to read sector config: %w", err)
+	}
+
+	var cfg UniverseConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return UniverseConfig{}, fmt.Errorf("failed to parse sector config %s: %w", path, err)
+	}
+
+	// Top-level sectors replace the default universe
+	if len(cfg.Sectors) > 0 {
+		name := cfg.Default
+		if name == "" {
+			name = DefaultUniverseName
+		}
+		cfg.Universes = append([]SectorUniverse{{
+			Name:      name,
+			Benchmark: cfg.Benchmark,
+			Sectors:   cfg.Sectors,
+		}}, cfg.Universes...)
+		cfg.Sectors = nil
+	}
+
+	for i := range cfg.Universes {
+		cfg.Universes[i].normalize()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return UniverseConfig{}, fmt.Errorf("invalid sector config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks every universe in the config and that the default exists.
+func (c UniverseConfig) Validate() error {
+	var errs []error
+
+	if len(c.Universes) == 0 {
+		errs = append(errs, errors.New("no universes or sectors defined"))
+	}
+
+	seen := make(map[string]bool)
+	for i, u := range c.Universes {
+		if u.Name == "" {
+			errs = append(errs, fmt.Errorf("universe #%d has no name", i+1))
+			continue
+		}
+		if seen[u.Name] {
+			errs = append(errs, fmt.Errorf("universe %q is defined more than once", u.Name))
+		}
+		seen[u.Name] = true
+
+		if err := u.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("universe %q: %w", u.Name, err))
+		}
+	}
+
+	if c.Default != "" && !seen[c.Default] {
+		if _, builtin := Universes[c.Default]; !builtin {
+			errs = append(errs, fmt.Errorf("default universe %q is not defined", c.Default))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// normalize trims names and upper-cases tickers and currency codes,
+// defaulting the benchmark and currency.
+func (u *SectorUniverse) normalize() {
+	u.Name = strings.TrimSpace(u.Name)
+	u.Benchmark = strings.ToUpper(strings.TrimSpace(u.Benchmark))
+	if u.Benchmark == "" {
+		u.Benchmark = MarketBenchmark
+	}
+	u.Currency = strings.ToUpper(strings.TrimSpace(u.Currency))
+	if u.Currency == "" {
+		u.Currency = BaseCurrency
+	}
+	u.ConvertTo = strings.ToUpper(strings.TrimSpace(u.ConvertTo))
+	for i := range u.Sectors {
+		u.Sectors[i].Name = strings.TrimSpace(u.Sectors[i].Name)
+		u.Sectors[i].ETF = strings.ToUpper(strings.TrimSpace(u.Sectors[i].ETF))
+		u.Sectors[i].Currency = strings.ToUpper(strings.TrimSpace(u.Sectors[i].Currency))
+	}
+}
+
+// Validate checks that every sector is named and has its own ETF. BLS series
+// and Damodaran industries are optional and may be shared between sectors;
+// sectors without them get neutral growth and innovation scores.
+func (u SectorUniverse) Validate() error {
+	var errs []error
+
+	if len(u.Sectors) == 0 {
+		errs = append(errs, errors.New("no sectors defined"))
+	}
+	if strings.TrimSpace(u.Benchmark) == "" {
+		errs = append(errs, errors.New("benchmark ticker is empty"))
+	}
+	if err := validateCurrency(u.Currency, u.ConvertTo != ""); err != nil {
+		errs = append(errs, fmt.Errorf("currency: %w", err))
+	}
+	if u.ConvertTo != "" {
+		if err := validateCurrency(u.ConvertTo, true); err != nil {
+			errs = append(errs, fmt.Errorf("convert_to: %w", err))
+		}
+	}
+
+	names := make(map[string]bool)
+	tickers := make(map[string]string)
+	for i, s := range u.Sectors {
+		name := strings.TrimSpace(s.Name)
+		if name == "" {
+			errs = append(errs, fmt.Errorf("sector #%d has no name", i+1))
+			continue
+		}
+		if strings.HasPrefix(name, "_") {
+			errs = append(errs, fmt.Errorf("sector %q: names starting with '_' are reserved", name))
+		}
+		if names[name] {
+			errs = append(errs, fmt.Errorf("sector %q is defined more than once", name))
+		}
+		names[name] = true
+
+		etf := strings.ToUpper(strings.TrimSpace(s.ETF))
+		if etf == "" {
+			errs = append(errs, fmt.Errorf("sector %q has no ETF", name))
+		} else if other, ok := tickers[etf]; ok {
+			errs = append(errs, fmt.Errorf("ETF %s is used by both %q and %q", etf, other, name))
+		} else {
+			tickers[etf] = name
+		}
+		if etf != "" && etf == strings.ToUpper(u.Benchmark) {
+			errs = append(errs, fmt.Errorf("sector %q uses the benchmark %s as its ETF", name, etf))
+		}
+
+		if s.Currency != "" {
+			if err := validateCurrency(s.Currency, u.ConvertTo != ""); err != nil {
+				errs = append(errs, fmt.Errorf("sector %q currency: %w", name, err))
+			}
+		}
+
+		if s.BLSSeries != "" && !strings.HasPrefix(s.BLSSeries, "CE") {
+			errs = append(errs, fmt.Errorf("sector %q has BLS series %s, expected a CES employment series", name, s.BLSSeries))
+		}
+
+		for _, industry := range s.DamodaranIndustries {
+			if strings.TrimSpace(industry) == "" {
+				errs = append(errs, fmt.Errorf("sector %q has an empty Damodaran industry", name))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateCurrency checks a currency code and, when it must be converted,
+// that FRED has an exchange rate series for it.
+func validateCurrency(code string, convertible bool) error {
+	if code == "" {
+		return nil
+	}
+	if len(code) != 3 || strings.ToUpper(code) != code {
+		return fmt.Errorf("%q is not an ISO currency code", code)
+	}
+	if _, ok := FXSeries[code]; convertible && !ok && code != BaseCurrency {
+		return fmt.Errorf("no FX series configured for %s", code)
+	}
+	return nil
+}
+
+// ApplyUniverseConfig registers the config's universes, replacing built-in
+// universes of the same name. The package-level sector mappings are updated
+// to reflect the default universe. It must be called at startup, before any
+// data is fetched.
+func ApplyUniverseConfig(cfg UniverseConfig) {
+	for _, u := range cfg.Universes {
+		Universes[u.Name] = u
+	}
+	if cfg.Default != "" {
+		DefaultUniverseName = cfg.Default
+	}
+
+	u := DefaultUniverse()
+	etfs := make(map[string]string)
+	blsSeries := make(map[string]string)
+	industries := make(map[string]string)
+	for _, s := range u.Sectors {
+		etfs[s.Name] = s.ETF
+		if s.BLSSeries != "" {
+			blsSeries[s.Name] = s.BLSSeries
+		}
+		for _, industry := range s.DamodaranIndustries {
+			industries[industry] = s.Name
+		}
+	}
+
+	SectorETFs = etfs
+	SectorNames = u.SectorNames()
+	BLSEmploymentSeries = blsSeries
+	DamodaranToGICS = industries
+	MarketBenchmark = u.Benchmark
+}
+
+// LoadSectorConfigFromEnv applies the file named by SECTOR_CONFIG, if set.
+// It returns the path that was loaded, or "" when the built-in defaults are used.
+func LoadSectorConfigFromEnv() (string, error) {
+	path := os.Getenv(SectorConfigEnv)
+	if path == "" {
+		return "", nil
+	}
+
+	cfg, err := LoadUniverseConfig(path)
+	if err != nil {
+		return "", err
+	}
+	ApplyUniverseConfig(cfg)
+	return path, nil
+}
+
+// invertDamodaranMapping turns an industry->sector map into sector->industries.
+func invertDamodaranMapping(mapping map[string]string) map[string][]string {
+	industries := make(map[string][]string)
+	for industry, sector := range mapping {
+		industries[sector] = append(industries[sector], industry)
+	}
+	for _, list := range industries {
+		sort.Strings(list)
+	}
+	return industries
+}
@@ -0,0 +1,162 @@
+// Custom signal definitions loaded from a YAML or JSON file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SignalConfigEnv names the environment variable pointing at a custom signal file.
+const SignalConfigEnv = "SIGNAL_CONFIG"
+
+// SignalNormalizations lists how a custom signal's raw values can be put on
+// the 0-100 scale: "zscore" maps the cross-sectional z-score to 50 ± 15 per
+// standard deviation, "minmax" spreads the lowest to highest value over
+// 0-100, "rank" scores percentile ranks, and "none" takes the values as
+// scores already, clamped to 0-100.
+var SignalNormalizations = []string{"zscore", "minmax", "rank", "none"}
+
+// SignalDefinition describes a signal computed from an arithmetic
+// expression over sector metrics, such as "0.6*ret_6mo + 0.4*rel_strength"
+// or "1/forward_pe". Higher values of the expression score higher. Weight is
+// its default weight in the opportunity score.
+type SignalDefinition struct {
+	Name        string  `yaml:"name" json:"name"`
+	Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+	Expression  string  `yaml:"expression" json:"expression"`
+	Normalize   string  `yaml:"normalize,omitempty" json:"normalize,omitempty"`
+	Weight      float64 `yaml:"weight,omitempty" json:"weight,omitempty"`
+}
+
+// SignalConfig is the contents of a custom signal file.
+type SignalConfig struct {
+	Signals []SignalDefinition `yaml:"signals" json:"signals"`
+}
+
+// CustomSignals holds the signal definitions loaded at startup.
+var CustomSignals []SignalDefinition
+
+// signalNamePattern matches names usable as a weight query parameter and
+// as the prefix of a "<name>_score" field.
+var signalNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ReservedSignalNames can't name a custom signal: the first three would
+// duplicate the opportunity_score, factor_score and cycle_score response
+// fields, and the rest are API query parameters that would be read as its
+// weight.
+var ReservedSignalNames = []string{
+	"opportunity", "factor", "cycle",
+	"universe", "refresh", "as_of", "model", "valuation_method",
+	"fundamentals_source", "momentum_indicators", "window", "method",
+	"min_correlation", "top", "min_weight", "max_weight", "risk_aversion",
+	"max_tracking_error", "benchmark", "portfolio", "days", "months",
+	"scenario", "shock", "propagate", "from", "to",
+}
+
+// LoadSignalConfig reads and validates a custom signal file. Expressions
+// are only checked to be present here; they are parsed when the signals
+// are registered.
+func LoadSignalConfig(path string) (SignalConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return SignalConfig{}, fmt.Errorf("failed to read signal config: %w", err)
+	}
+
+	var cfg SignalConfig
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return SignalConfig{}, fmt.Errorf("failed to parse signal config %s: %w", path, err)
+	}
+
+	for i := range cfg.Signals {
+		s := &cfg.Signals[i]
+		s.Name = strings.TrimSpace(s.Name)
+		s.Expression = strings.TrimSpace(s.Expression)
+		s.Normalize = strings.ToLower(strings.TrimSpace(s.Normalize))
+		if s.Normalize == "" {
+			s.Normalize = "zscore"
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return SignalConfig{}, fmt.Errorf("invalid signal config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that every signal has a unique, well-formed name that
+// doesn't replace a built-in signal or a reserved name, an expression, a
+// known normalization and a weight between 0 and 1.
+func (c SignalConfig) Validate() error {
+	var errs []error
+
+	if len(c.Signals) == 0 {
+		errs = append(errs, errors.New("no signals defined"))
+	}
+
+	seen := make(map[string]bool)
+	for i, s := range c.Signals {
+		if s.Name == "" {
+			errs = append(errs, fmt.Errorf("signal #%d has no name", i+1))
+			continue
+		}
+		if !signalNamePattern.MatchString(s.Name) {
+			errs = append(errs, fmt.Errorf("signal %q: name must be lower case letters, digits and underscores, starting with a letter", s.Name))
+		}
+		if _, builtin := DefaultWeights[s.Name]; builtin {
+			errs = append(errs, fmt.Errorf("signal %q: name is taken by a built-in signal", s.Name))
+		}
+		if slices.Contains(ReservedSignalNames, s.Name) {
+			errs = append(errs, fmt.Errorf("signal %q: name is reserved", s.Name))
+		}
+		if seen[s.Name] {
+			errs = append(errs, fmt.Errorf("signal %q is defined more than once", s.Name))
+		}
+		seen[s.Name] = true
+
+		if s.Expression == "" {
+			errs = append(errs, fmt.Errorf("signal %q has no expression", s.Name))
+		}
+		if !slices.Contains(SignalNormalizations, s.Normalize) {
+			errs = append(errs, fmt.Errorf("signal %q: normalize must be one of: %s", s.Name, strings.Join(SignalNormalizations, ", ")))
+		}
+		if s.Weight < 0 || s.Weight > 1 {
+			errs = append(errs, fmt.Errorf("signal %q: weight must be between 0 and 1", s.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ApplySignalConfig records the config's signals and their default weights.
+// The default weights then sum to more than one and are scaled down in
+// proportion when scoring. It must be called at startup, before any scores
+// are computed.
+func ApplySignalConfig(cfg SignalConfig) {
+	CustomSignals = cfg.Signals
+	for _, s := range cfg.Signals {
+		DefaultWeights[s.Name] = s.Weight
+	}
+}
+
+// LoadSignalConfigFromEnv applies the file named by SIGNAL_CONFIG, if set.
+// It returns the path that was loaded, or "" when there are no custom signals.
+func LoadSignalConfigFromEnv() (string, error) {
+	path := os.Getenv(SignalConfigEnv)
+	if path == "" {
+		return "", nil
+	}
+
+	cfg, err := LoadSignalConfig(path)
+	if err != nil {
+		return "", err
+	}
+	ApplySignalConfig(cfg)
+	return path, nil
+}
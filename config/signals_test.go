@@ -0,0 +1,44 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignalConfigValidate(t *testing.T) {
+	signal := func(name string) SignalDefinition {
+		return SignalDefinition{Name: name, Expression: "ret_6mo", Normalize: "zscore", Weight: 0.1}
+	}
+
+	tests := []struct {
+		name    string
+		signals []SignalDefinition
+		want    string // "" when valid
+	}{
+		{"valid", []SignalDefinition{signal("risk_adjusted_momentum")}, ""},
+		{"no signals", nil, "no signals defined"},
+		{"built-in name", []SignalDefinition{signal("momentum")}, "taken by a built-in signal"},
+		{"opportunity score field", []SignalDefinition{signal("opportunity")}, `"opportunity": name is reserved`},
+		{"factor score field", []SignalDefinition{signal("factor")}, `"factor": name is reserved`},
+		{"cycle score field", []SignalDefinition{signal("cycle")}, `"cycle": name is reserved`},
+		{"query parameter", []SignalDefinition{signal("top")}, `"top": name is reserved`},
+		{"universe parameter", []SignalDefinition{signal("universe")}, `"universe": name is reserved`},
+		{"malformed name", []SignalDefinition{signal("Risk-Momentum")}, "name must be lower case"},
+		{"duplicate", []SignalDefinition{signal("quality_tilt"), signal("quality_tilt")}, "defined more than once"},
+		{"weight out of range", []SignalDefinition{{Name: "tilt", Expression: "ret_6mo", Normalize: "zscore", Weight: 2}}, "weight must be between 0 and 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SignalConfig{Signals: tt.signals}.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
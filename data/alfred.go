@@ -0,0 +1,258 @@
+// FRED vintage (ALFRED) data for as-of analysis.
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"sector-analyzer/config"
+)
+
+// Vintage is one published value of an observation. It was the current
+// value from RealtimeStart through RealtimeEnd, inclusive.
+type Vintage struct {
+	Date          time.Time `json:"date"`
+	RealtimeStart time.Time `json:"realtime_start"`
+	RealtimeEnd   time.Time `json:"realtime_end"`
+	Value         float64   `json:"value"`
+}
+
+// FetchFREDVintages retrieves every published value of a series' observations
+// since startDate. If FRED cannot be reached, previously stored vintages are
+// returned instead.
+func FetchFREDVintages(seriesID string, startDate time.Time) ([]Vintage, error) {
+	return fetchFREDVintages(seriesID, startDate, nil)
+}
+
+// fetchFREDVintages retrieves a series' vintages, reporting a fallback to
+// stored vintages and storage failures to progress as warnings.
+func fetchFREDVintages(seriesID string, startDate time.Time, progress ProgressFunc) ([]Vintage, error) {
+	cacheKey := GenerateKey("fred", map[string]interface{}{
+		"type":       "vintages",
+		"series_id":  seriesID,
+		"start_date": startDate.Format("2006-01-02"),
+	})
+	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		return cached.([]Vintage), nil
+	}
+
+	vintages, err := requestFREDVintages(seriesID, startDate)
+	if err != nil {
+		stored, ok, loadErr := GlobalStore.LoadVintages(seriesID)
+		if loadErr != nil || !ok {
+			return nil, err
+		}
+		report(progress, SourceFRED, seriesID, StageWarning,
+			fmt.Sprintf("Warning: Could not fetch vintages for %s: %v. Using stored vintages.", seriesID, err))
+		return stored, nil
+	}
+
+	if err := GlobalStore.SaveVintages(seriesID, vintages); err != nil {
+		report(progress, SourceFRED, seriesID, StageWarning,
+			fmt.Sprintf("Warning: Could not store vintages for %s: %v", seriesID, err))
+	}
+	GlobalCache.Set(cacheKey, vintages)
+	return vintages, nil
+}
+
+// requestFREDVintages downloads all real-time periods of a series from ALFRED.
+func requestFREDVintages(seriesID string, startDate time.Time) ([]Vintage, error) {
+	apiKey := os.Getenv("FRED_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("FRED_API_KEY not set")
+	}
+
+	// The full real-time range returns every vintage, not just the latest
+	apiURL := fmt.Sprintf(
+		"https://api.stlouisfed.org/fred/series/observations?series_id=%s&api_key=%s&file_type=json&observation_start=%s&realtime_start=1776-07-04&realtime_end=9999-12-31",
+		seriesID,
+		apiKey,
+		startDate.Format("2006-01-02"),
+	)
+
+	resp, err := http.Get(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("FRED API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var fredResp FREDResponse
+	if err := json.Unmarshal(body, &fredResp); err != nil {
+		return nil, err
+	}
+
+	var vintages []Vintage
+	for _, obs := range fredResp.Observations {
+		if obs.Value == "." {
+			continue // Skip missing values
+		}
+
+		date, err := time.Parse("2006-01-02", obs.Date)
+		if err != nil {
+			continue
+		}
+		start, err := time.Parse("2006-01-02", obs.RealtimeStart)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse("2006-01-02", obs.RealtimeEnd)
+		if err != nil {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(obs.Value, 64)
+		if err != nil {
+			continue
+		}
+
+		vintages = append(vintages, Vintage{Date: date, RealtimeStart: start, RealtimeEnd: end, Value: value})
+	}
+
+	return vintages, nil
+}
+
+// SeriesAsOf reconstructs a series as it was published on asOf: each
+// observation takes the value current on that day, and observations not yet
+// released are omitted.
+func SeriesAsOf(vintages []Vintage, asOf time.Time) TimeSeries {
+	day := truncateDay(asOf)
+
+	var points []Vintage
+	for _, v := range vintages {
+		if !v.RealtimeStart.After(day) && !v.RealtimeEnd.Before(day) {
+			points = append(points, v)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date) })
+
+	ts := TimeSeries{
+		Dates:  make([]time.Time, 0, len(points)),
+		Values: make([]float64, 0, len(points)),
+	}
+	for _, p := range points {
+		ts.Dates = append(ts.Dates, p.Date)
+		ts.Values = append(ts.Values, p.Value)
+	}
+	return ts
+}
+
+// fetchMacroDataAsOf retrieves the FRED series as they were published on asOf.
+// Series without vintages are omitted rather than filled with later values.
+func fetchMacroDataAsOf(yearsBack int, asOf time.Time, progress ProgressFunc) (MacroData, error) {
+	startDate := asOf.AddDate(-yearsBack, 0, 0)
+	data := make(MacroData)
+
+	var lastErr error
+	for name, seriesID := range config.FREDSeries {
+		vintages, err := fetchFREDVintages(seriesID, startDate, progress)
+		if err != nil {
+			report(progress, SourceFRED, seriesID, StageFailed,
+				fmt.Sprintf("Error fetching vintages for %s: %v", seriesID, err))
+			lastErr = err
+			continue
+		}
+		ts := SeriesAsOf(vintages, asOf)
+		if len(ts.Values) == 0 {
+			continue
+		}
+		data[name] = ts
+		report(progress, SourceFRED, seriesID, StageProgress,
+			fmt.Sprintf("%d observations of %s published by %s", len(ts.Values), seriesID, asOf.Format("2006-01-02")))
+	}
+
+	if len(data) == 0 && lastErr != nil {
+		return data, lastErr
+	}
+	return data, nil
+}
+
+// DataAsOf derives the data that was available on asOf from the latest data
+// of a universe: prices end at asOf, FRED series use the vintages published
+// by then, and BLS months count only once released (at their latest revised
+// values, as BLS has no vintage API). P/E ratios have no history, so
+// SectorInfo and the ETF holdings are left empty. R&D figures come
+// from the latest Damodaran dataset published by asOf.
+func DataAsOf(latest *AllData, asOf time.Time, progress ProgressFunc) (*AllData, error) {
+	if latest == nil {
+		return nil, fmt.Errorf("no data loaded")
+	}
+	if asOf.After(latest.FetchedAt) {
+		return nil, fmt.Errorf("as-of date %s is after the data was fetched", asOf.Format("2006-01-02"))
+	}
+
+	endOfDay := truncateDay(asOf).AddDate(0, 0, 1)
+	prices := make(SectorPrices, len(latest.SectorPrices))
+	for key, series := range latest.SectorPrices {
+		i := sort.Search(len(series), func(i int) bool { return !series[i].Date.Before(endOfDay) })
+		if i > 0 {
+			prices[key] = series[:i]
+		}
+	}
+
+	employment := make(EmploymentData, len(latest.EmploymentData))
+	for sector, ts := range latest.EmploymentData {
+		var published TimeSeries
+		for i, month := range ts.Dates {
+			if !blsReleaseDate(month).After(asOf) {
+				published.appendPoint(month, ts.Values[i], ts.IsPreliminary(i))
+			}
+		}
+		if len(published.Dates) > 0 {
+			employment[sector] = published
+		}
+	}
+
+	failed := make(map[string]string, len(latest.FailedSources))
+	for source, msg := range latest.FailedSources {
+		failed[source] = msg
+	}
+	macro, err := fetchMacroDataAsOf(config.MacroSensitivityYears, asOf, progress)
+	if err != nil {
+		failed[SourceFRED] = err.Error()
+	}
+
+	asOfCopy := asOf
+	return &AllData{
+		Universe:       latest.Universe,
+		Benchmark:      latest.Benchmark,
+		Currency:       latest.Currency,
+		Sectors:        latest.Sectors,
+		SectorPrices:   prices,
+		SectorInfo:     map[string]SectorInfo{},
+		MacroData:      macro,
+		EmploymentData: employment,
+		RDData:         rdAsOf(latest.RDData, latest.RDHistory, asOf),
+		RDHistory:      latest.RDHistory,
+		Industries:     latest.Industries,
+		FailedSources:  failed,
+		Issues:         latest.Issues,
+		FetchedAt:      latest.FetchedAt,
+		AsOf:           &asOfCopy,
+	}, nil
+}
+
+// blsReleaseDate approximates when a CES month is first published: the
+// Employment Situation release on the first Friday of the following month.
+func blsReleaseDate(month time.Time) time.Time {
+	d := time.Date(month.Year(), month.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	for d.Weekday() != time.Friday {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
@@ -0,0 +1,230 @@
+// The BLS Public Data API client.
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blsEndpoint is a version of the BLS Public Data API and the request limits
+// it enforces. Requests beyond the limits are not rejected: BLS silently drops
+// the extra series or shortens the year range, so requests are chunked to fit.
+type blsEndpoint struct {
+	Name      string
+	URL       string
+	MaxSeries int
+	MaxYears  int
+}
+
+var (
+	// blsV2 requires a registration key (BLS_API_KEY).
+	blsV2 = blsEndpoint{
+		Name:      "v2",
+		URL:       "https://api.bls.gov/publicAPI/v2/timeseries/data/",
+		MaxSeries: 50,
+		MaxYears:  20,
+	}
+	// blsV1 needs no key and is used without one, or when a v2 request fails.
+	blsV1 = blsEndpoint{
+		Name:      "v1",
+		URL:       "https://api.bls.gov/publicAPI/v1/timeseries/data/",
+		MaxSeries: 25,
+		MaxYears:  10,
+	}
+)
+
+// blsSeriesPattern finds the series ID a BLS message refers to, as in
+// "No Data Available for Series CES0000000001 Year: 2019".
+var blsSeriesPattern = regexp.MustCompile(`Series ([A-Z0-9]{6,})`)
+
+// blsResult is the merged outcome of a chunked BLS request. Warnings are
+// keyed by series ID; messages that name no series are keyed by "".
+type blsResult struct {
+	Series   map[string]TimeSeries
+	Warnings map[string][]string
+}
+
+func (r *blsResult) warn(seriesID, message string) {
+	r.Warnings[seriesID] = append(r.Warnings[seriesID], message)
+}
+
+// requestBLSSeries downloads monthly observations for the given series IDs,
+// splitting the request by series and year range to stay within the API's
+// limits and merging the partial responses. A failed chunk becomes a warning
+// for each of its series; an error is returned only if no chunk succeeded.
+// Retries against the v1 API are reported to progress as warnings.
+func requestBLSSeries(seriesIDs []string, startYear, endYear int, progress ProgressFunc) (blsResult, error) {
+	result := blsResult{
+		Series:   make(map[string]TimeSeries),
+		Warnings: make(map[string][]string),
+	}
+
+	apiKey := os.Getenv("BLS_API_KEY")
+	endpoint := blsV1
+	if apiKey != "" {
+		endpoint = blsV2
+	}
+
+	points := make(map[string]map[time.Time]blsPoint)
+	succeeded, lastErr := requestBLSChunks(endpoint, apiKey, seriesIDs, startYear, endYear, points, &result, progress)
+	if succeeded == 0 && lastErr != nil {
+		return result, lastErr
+	}
+
+	for id, byDate := range points {
+		var ts TimeSeries
+		for date, p := range byDate {
+			ts.appendPoint(date, p.value, p.preliminary)
+		}
+		sortTimeSeries(&ts)
+		result.Series[id] = ts
+	}
+	return result, nil
+}
+
+// blsPoint is one parsed observation.
+type blsPoint struct {
+	value       float64
+	preliminary bool
+}
+
+// requestBLSChunks requests every series and year-range chunk from an
+// endpoint, adding observations to points. A v2 chunk that fails is retried
+// against v1. It returns the number of chunks that succeeded and the last error.
+func requestBLSChunks(endpoint blsEndpoint, apiKey string, seriesIDs []string, startYear, endYear int,
+	points map[string]map[time.Time]blsPoint, result *blsResult, progress ProgressFunc) (int, error) {
+	succeeded := 0
+	var lastErr error
+
+	for first := 0; first < len(seriesIDs); first += endpoint.MaxSeries {
+		last := first + endpoint.MaxSeries
+		if last > len(seriesIDs) {
+			last = len(seriesIDs)
+		}
+		ids := seriesIDs[first:last]
+
+		for from := startYear; from <= endYear; from += endpoint.MaxYears {
+			to := from + endpoint.MaxYears - 1
+			if to > endYear {
+				to = endYear
+			}
+
+			resp, err := postBLS(endpoint, apiKey, ids, from, to)
+			if err != nil && endpoint == blsV2 {
+				report(progress, SourceBLS, "", StageWarning,
+					fmt.Sprintf("Warning: BLS %s request for %d-%d failed: %v. Retrying with %s.",
+						endpoint.Name, from, to, err, blsV1.Name))
+				n, retryErr := requestBLSChunks(blsV1, "", ids, from, to, points, result, progress)
+				succeeded += n
+				if retryErr != nil {
+					lastErr = retryErr
+				}
+				continue
+			}
+			if err != nil {
+				for _, id := range ids {
+					result.warn(id, fmt.Sprintf("%d-%d not fetched: %v", from, to, err))
+				}
+				lastErr = err
+				continue
+			}
+
+			succeeded++
+			for _, msg := range resp.Message {
+				id := ""
+				if m := blsSeriesPattern.FindStringSubmatch(msg); m != nil {
+					id = m[1]
+				}
+				result.warn(id, msg)
+			}
+			addBLSObservations(resp, points)
+		}
+	}
+
+	return succeeded, lastErr
+}
+
+// postBLS sends one request, which must be within the endpoint's limits.
+func postBLS(endpoint blsEndpoint, apiKey string, seriesIDs []string, startYear, endYear int) (BLSResponse, error) {
+	var blsResp BLSResponse
+
+	payload := map[string]interface{}{
+		"seriesid":  seriesIDs,
+		"startyear": strconv.Itoa(startYear),
+		"endyear":   strconv.Itoa(endYear),
+	}
+	if apiKey != "" {
+		payload["registrationkey"] = apiKey
+	}
+
+	payloadBytes, _ := json.Marshal(payload)
+	resp, err := http.Post(endpoint.URL, "application/json", strings.NewReader(string(payloadBytes)))
+	if err != nil {
+		return blsResp, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return blsResp, fmt.Errorf("BLS API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return blsResp, err
+	}
+	if err := json.Unmarshal(body, &blsResp); err != nil {
+		return blsResp, err
+	}
+
+	if blsResp.Status != "REQUEST_SUCCEEDED" {
+		return blsResp, fmt.Errorf("BLS API error: %s: %v", blsResp.Status, blsResp.Message)
+	}
+	return blsResp, nil
+}
+
+// addBLSObservations parses a response's monthly observations into points.
+// Values footnoted "P" are preliminary and replaced by final values in later
+// releases.
+func addBLSObservations(resp BLSResponse, points map[string]map[time.Time]blsPoint) {
+	for _, series := range resp.Results.Series {
+		byDate := points[series.SeriesID]
+		if byDate == nil {
+			byDate = make(map[time.Time]blsPoint)
+			points[series.SeriesID] = byDate
+		}
+
+		for _, item := range series.Data {
+			year, _ := strconv.Atoi(item.Year)
+			monthStr := strings.TrimPrefix(item.Period, "M")
+			month, _ := strconv.Atoi(monthStr)
+
+			// M13 is the annual average
+			if month < 1 || month > 12 {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(item.Value, 64)
+			if err != nil {
+				continue
+			}
+
+			preliminary := false
+			for _, note := range item.Footnotes {
+				if note.Code == "P" {
+					preliminary = true
+				}
+			}
+
+			date := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+			byDate[date] = blsPoint{value: value, preliminary: preliminary}
+		}
+	}
+}
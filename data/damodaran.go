@@ -0,0 +1,427 @@
+// Parsing of Damodaran's industry datasets and archives.
+
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/extrame/xls"
+	"github.com/xuri/excelize/v2"
+	"sector-analyzer/config"
+)
+
+// sheetColumn matches header cells, lower-cased with spaces collapsed, to a
+// field of a table. Columns are found by header rather than position because
+// layouts shift between releases and providers.
+type sheetColumn struct {
+	name     string
+	required bool
+	matches  func(h string) bool
+}
+
+// damodaranColumns are the fields read from a Damodaran industry table.
+var damodaranColumns = []sheetColumn{
+	{"industry", true, func(h string) bool { return strings.HasPrefix(h, "industry") }},
+	{"rd_revenue", true, func(h string) bool {
+		return strings.Contains(h, "r&d") && mentionsRevenue(h)
+	}},
+	{"firms", false, func(h string) bool { return strings.Contains(h, "number of firms") }},
+	{"rd", false, func(h string) bool {
+		return strings.Contains(h, "r&d") && !mentionsRevenue(h) &&
+			!containsAny(h, "%", "growth", "ago", "prev", "last", "capitaliz", "amortiz")
+	}},
+	{"revenue", false, func(h string) bool {
+		return mentionsRevenue(h) && !containsAny(h, "r&d", "%", "/", "growth", "ago", "prev", "last")
+	}},
+}
+
+func mentionsRevenue(h string) bool {
+	return strings.Contains(h, "revenue") || strings.Contains(h, "sales")
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// damodaranArchiveName matches archive files such as R&D19.xls or R&D2019.xlsx.
+var damodaranArchiveName = regexp.MustCompile(`(?i)^r&d(\d{2}|\d{4})\.xlsx?$`)
+
+// sheetTable is a table located by its header row.
+type sheetTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// cell returns the trimmed value of a named column, or "" if the table has
+// no such column or the row is short.
+func (t sheetTable) cell(row []string, column string) string {
+	i, ok := t.columns[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// fetchDamodaranExcel reads the current Damodaran R&D dataset, from
+// config.DamodaranRDPath when set and otherwise from Damodaran's site.
+func fetchDamodaranExcel() (map[string]IndustryRD, error) {
+	source := config.DamodaranRDURL
+	if config.DamodaranRDPath != "" {
+		source = config.DamodaranRDPath
+	}
+
+	content, err := readDamodaranFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return parseDamodaranRD(content)
+}
+
+// readDamodaranFile reads a dataset from a URL or a local path.
+func readDamodaranFile(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		return content, nil
+	}
+
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d from Damodaran", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, nil
+}
+
+// parseDamodaranRD extracts each industry's R&D figures. Where the dataset
+// has R&D dollars but no revenue column, revenue is derived from R&D and its
+// share of revenue.
+func parseDamodaranRD(content []byte) (map[string]IndustryRD, error) {
+	sheets, err := readWorkbook(content)
+	if err != nil {
+		return nil, err
+	}
+	table, err := findTable(sheets, damodaranColumns)
+	if err != nil {
+		return nil, fmt.Errorf("no sheet has an industry and R&D as %% of revenue header")
+	}
+
+	industries := make(map[string]IndustryRD)
+	for _, row := range table.rows {
+		industry := table.cell(row, "industry")
+		if industry == "" {
+			continue
+		}
+
+		// Values are fractions (0.15 = 15%); anything outside [0, 1] is a
+		// footnote or an error cell
+		rdValue, ok := parseSheetNumber(table.cell(row, "rd_revenue"))
+		if !ok || rdValue < 0 || rdValue > 1 {
+			continue
+		}
+
+		rec := IndustryRD{Intensity: rdValue}
+		if firms, ok := parseSheetNumber(table.cell(row, "firms")); ok && firms > 0 {
+			rec.Firms = int(firms)
+		}
+		if rd, ok := parseSheetNumber(table.cell(row, "rd")); ok && rd > 0 {
+			rec.RD = rd
+		}
+		if revenue, ok := parseSheetNumber(table.cell(row, "revenue")); ok && revenue > 0 {
+			rec.Revenue = revenue
+		} else if rec.RD > 0 && rdValue > 0 {
+			rec.Revenue = rec.RD / rdValue
+		}
+
+		industries[industry] = rec
+	}
+
+	if len(industries) == 0 {
+		return nil, fmt.Errorf("no industry R&D values found")
+	}
+	return industries, nil
+}
+
+// findTable returns the first table, on any sheet, whose header row has
+// every required column. When several cells match a column, one mentioning
+// "current" is preferred over prior-year figures.
+func findTable(sheets [][][]string, want []sheetColumn) (sheetTable, error) {
+	const maxHeaderRow = 50
+
+	for _, rows := range sheets {
+		for r := 0; r < len(rows) && r < maxHeaderRow; r++ {
+			columns := make(map[string]int)
+			for c, raw := range rows[r] {
+				h := strings.ToLower(strings.Join(strings.Fields(raw), " "))
+				if h == "" {
+					continue
+				}
+				for _, col := range want {
+					if !col.matches(h) {
+						continue
+					}
+					if _, seen := columns[col.name]; !seen || strings.Contains(h, "current") {
+						columns[col.name] = c
+					}
+				}
+			}
+			if hasRequiredColumns(want, columns) {
+				return sheetTable{columns: columns, rows: rows[r+1:]}, nil
+			}
+		}
+	}
+	return sheetTable{}, fmt.Errorf("no header row found")
+}
+
+func hasRequiredColumns(want []sheetColumn, columns map[string]int) bool {
+	for _, col := range want {
+		if _, ok := columns[col.name]; col.required && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// readWorkbook returns the cell text of every sheet in an .xls or .xlsx
+// file, telling the formats apart by their leading bytes.
+func readWorkbook(content []byte) ([][][]string, error) {
+	switch {
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")):
+		return readXLSX(content)
+	case bytes.HasPrefix(content, []byte{0xD0, 0xCF, 0x11, 0xE0}):
+		return readXLS(content)
+	default:
+		return nil, fmt.Errorf("not an Excel file")
+	}
+}
+
+// readXLSX reads an .xlsx workbook. Raw values are used so percentages keep
+// their fractional form rather than the displayed "15.00%".
+func readXLSX(content []byte) ([][][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Excel: %w", err)
+	}
+	defer f.Close()
+
+	var sheets [][][]string
+	for _, name := range f.GetSheetList() {
+		rows, err := f.GetRows(name, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %s: %w", name, err)
+		}
+		sheets = append(sheets, rows)
+	}
+	return sheets, nil
+}
+
+// readXLS reads a legacy .xls workbook.
+func readXLS(content []byte) ([][][]string, error) {
+	xlsFile, err := xls.OpenReader(bytes.NewReader(content), "utf-8")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Excel: %w", err)
+	}
+
+	var sheets [][][]string
+	for i := 0; i < xlsFile.NumSheets(); i++ {
+		sheet := xlsFile.GetSheet(i)
+		if sheet == nil {
+			continue
+		}
+
+		rows := make([][]string, int(sheet.MaxRow)+1)
+		for r := range rows {
+			row := xlsRow(sheet, r)
+			if row == nil {
+				continue
+			}
+			cells := make([]string, row.LastCol()+1)
+			for c := row.FirstCol(); c <= row.LastCol(); c++ {
+				cells[c] = row.Col(c)
+			}
+			rows[r] = cells
+		}
+		sheets = append(sheets, rows)
+	}
+	return sheets, nil
+}
+
+// xlsRow returns row i of a sheet, or nil if it holds no cells (the xls
+// package panics on those).
+func xlsRow(sheet *xls.WorkSheet, i int) (row *xls.Row) {
+	defer func() {
+		if recover() != nil {
+			row = nil
+		}
+	}()
+	return sheet.Row(i)
+}
+
+// parseSheetNumber parses a cell as a number, accepting thousands separators,
+// currency signs and percentages.
+func parseSheetNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.NewReplacer(",", "", "$", "").Replace(s))
+	scale := 1.0
+	if strings.HasSuffix(s, "%") {
+		s = strings.TrimSuffix(s, "%")
+		scale = 0.01
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * scale, true
+}
+
+// fetchDamodaranArchives returns the industry R&D of each archived dataset,
+// by year of publication. Archives never change, so each is read once and
+// then served from the store; archives that cannot be read are skipped and
+// reported to progress as warnings.
+func fetchDamodaranArchives(progress ProgressFunc) map[int]map[string]IndustryRD {
+	cacheKey := GenerateKey("damodaran", map[string]interface{}{"type": "industry_rd_archives"})
+	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		return cached.(map[int]map[string]IndustryRD)
+	}
+
+	archives := make(map[int]map[string]IndustryRD)
+	for year, source := range damodaranArchiveSources(progress) {
+		if rec, ok, err := GlobalStore.LoadRDArchive(year); err == nil && ok {
+			archives[year] = rec.Industries
+			continue
+		}
+
+		content, err := readDamodaranFile(source)
+		var industries map[string]IndustryRD
+		if err == nil {
+			industries, err = parseDamodaranRD(content)
+		}
+		if err != nil {
+			report(progress, SourceDamodaran, "", StageWarning,
+				fmt.Sprintf("Warning: Could not read Damodaran archive for %d: %v", year, err))
+			continue
+		}
+
+		archives[year] = industries
+		if err := GlobalStore.SaveRDArchive(year, IndustryRDRecord{Industries: industries, FetchedAt: time.Now()}); err != nil {
+			report(progress, SourceDamodaran, "", StageWarning,
+				fmt.Sprintf("Warning: Could not store Damodaran archive for %d: %v", year, err))
+		}
+	}
+
+	GlobalCache.Set(cacheKey, archives)
+	return archives
+}
+
+// damodaranArchiveSources lists the archives to ingest by year: every archive
+// in config.DamodaranArchiveDir when set, otherwise the last
+// config.DamodaranArchiveYears on Damodaran's site.
+func damodaranArchiveSources(progress ProgressFunc) map[int]string {
+	sources := make(map[int]string)
+	thisYear := time.Now().Year()
+
+	if config.DamodaranArchiveDir == "" {
+		for year := thisYear - config.DamodaranArchiveYears; year < thisYear; year++ {
+			sources[year] = fmt.Sprintf(config.DamodaranArchiveURL, year%100)
+		}
+		return sources
+	}
+
+	entries, err := os.ReadDir(config.DamodaranArchiveDir)
+	if err != nil {
+		report(progress, SourceDamodaran, "", StageWarning,
+			fmt.Sprintf("Warning: Could not read Damodaran archive directory: %v", err))
+		return sources
+	}
+	for _, entry := range entries {
+		m := damodaranArchiveName.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() {
+			continue
+		}
+		year, _ := strconv.Atoi(m[1])
+		if year < 100 {
+			year += 2000
+		}
+		sources[year] = filepath.Join(config.DamodaranArchiveDir, entry.Name())
+	}
+	return sources
+}
+
+// fetchRDHistory builds each sector's R&D intensity by dataset year from the
+// archives, ending with current. Points are dated January 1 of the year
+// Damodaran published them.
+func fetchRDHistory(u config.SectorUniverse, current RDData, progress ProgressFunc) RDHistory {
+	archives := fetchDamodaranArchives(progress)
+	thisYear := time.Now().Year()
+
+	years := make([]int, 0, len(archives))
+	for year := range archives {
+		if year < thisYear {
+			years = append(years, year)
+		}
+	}
+	sort.Ints(years)
+
+	history := make(RDHistory)
+	add := func(year int, values RDData) {
+		date := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		for sector, v := range values {
+			if v <= 0 {
+				continue
+			}
+			ts := history[sector]
+			ts.Dates = append(ts.Dates, date)
+			ts.Values = append(ts.Values, v)
+			history[sector] = ts
+		}
+	}
+	for _, year := range years {
+		add(year, sectorRD(u, archives[year]))
+	}
+	add(thisYear, current)
+
+	return history
+}
+
+// rdAsOf returns each sector's R&D intensity from the latest dataset
+// published by asOf. Sectors without history keep their latest value.
+func rdAsOf(latest RDData, history RDHistory, asOf time.Time) RDData {
+	result := make(RDData, len(latest))
+	for sector, v := range latest {
+		result[sector] = v
+		ts, ok := history[sector]
+		if !ok {
+			continue
+		}
+		i := sort.Search(len(ts.Dates), func(i int) bool { return ts.Dates[i].After(asOf) })
+		if i > 0 {
+			result[sector] = ts.Values[i-1]
+		}
+	}
+	return result
+}
@@ -0,0 +1,216 @@
+package data
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"sector-analyzer/config"
+)
+
+func TestFindTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheets  [][][]string
+		want    map[string]int // nil when no table is found
+		firstID string         // industry of the first data row
+	}{
+		{
+			name: "header below a title block",
+			sheets: [][][]string{{
+				{"Data Used: Multiple data services"},
+				{"Date of Analysis: Data used is as of January 2025"},
+				{},
+				{"Industry Name", "Number of firms", "R&D/Sales", "Current R&D", "R&D - 1 yr ago", "Revenues", "Revenue growth"},
+				{"Software (System & Application)", "353", "0.18", "60000", "55000", "333000", "0.12"},
+			}},
+			want:    map[string]int{"industry": 0, "firms": 1, "rd_revenue": 2, "rd": 3, "revenue": 5},
+			firstID: "Software (System & Application)",
+		},
+		{
+			name: "current figures preferred over earlier matches",
+			sheets: [][][]string{{
+				{"Industry  Name", "R&D as % of Revenue", "R&D (prior)", "Current   R&D"},
+				{"Semiconductor", "0.2", "1", "2"},
+			}},
+			want:    map[string]int{"industry": 0, "rd_revenue": 1, "rd": 3},
+			firstID: "Semiconductor",
+		},
+		{
+			name: "percent and growth columns are not R&D dollars or revenue",
+			sheets: [][][]string{{
+				{"Industry Name", "R&D/Sales", "R&D growth", "% of revenue", "Sales/Capital"},
+				{"Drugs (Pharmaceutical)", "0.16", "0.05", "0.1", "1.2"},
+			}},
+			want:    map[string]int{"industry": 0, "rd_revenue": 1},
+			firstID: "Drugs (Pharmaceutical)",
+		},
+		{
+			name: "table on a later sheet",
+			sheets: [][][]string{
+				{{"Variables and Definitions"}, {"R&D", "Research and development expenses"}},
+				{{"Industry Name", "R&D/Sales"}, {"Retail (General)", "0.01"}},
+			},
+			want:    map[string]int{"industry": 0, "rd_revenue": 1},
+			firstID: "Retail (General)",
+		},
+		{
+			name:   "no R&D share column",
+			sheets: [][][]string{{{"Industry Name", "Current R&D", "Revenues"}, {"Utility (General)", "1", "2"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := findTable(tt.sheets, damodaranColumns)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("found columns %v, want none", table.columns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(table.columns, tt.want) {
+				t.Errorf("columns = %v, want %v", table.columns, tt.want)
+			}
+			if len(table.rows) == 0 || table.cell(table.rows[0], "industry") != tt.firstID {
+				t.Errorf("rows = %v, want the first to be %q", table.rows, tt.firstID)
+			}
+		})
+	}
+}
+
+func TestParseSheetNumber(t *testing.T) {
+	tests := []struct {
+		cell string
+		want float64
+		ok   bool
+	}{
+		{"0.0825", 0.0825, true},
+		{" 12 ", 12, true},
+		{"1,234.5", 1234.5, true},
+		{"$2,500", 2500, true},
+		{"15%", 0.15, true},
+		{"-3.5%", -0.035, true},
+		{"", 0, false},
+		{"NA", 0, false},
+		{"#DIV/0!", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSheetNumber(tt.cell)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("parseSheetNumber(%q) = %g, %v, want %g, %v", tt.cell, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// xlsxWorkbook returns an .xlsx file with one sheet holding rows.
+func xlsxWorkbook(t *testing.T, rows [][]interface{}) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseDamodaranRD(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]interface{}
+		want map[string]IndustryRD
+	}{
+		{
+			name: "dollar figures",
+			rows: [][]interface{}{
+				{"Date updated: January 2025"},
+				{},
+				{"Industry Name", "Number of firms", "Current R&D", "Revenues", "R&D/Sales"},
+				{"Software", 353, 60000, 300000, 0.2},
+				{"Drugs", 250, "1,000", "$10,000", "10%"},
+				{"", 1, 1, 1, 0.5},
+				{"Footnote", "", "", "", 12},
+			},
+			want: map[string]IndustryRD{
+				"Software": {Firms: 353, RD: 60000, Revenue: 300000, Intensity: 0.2},
+				"Drugs":    {Firms: 250, RD: 1000, Revenue: 10000, Intensity: 0.1},
+			},
+		},
+		{
+			name: "revenue derived from R&D and its share",
+			rows: [][]interface{}{
+				{"Industry Name", "R&D Expenses", "R&D as % of Revenue"},
+				{"Semiconductor", 500, 0.25},
+			},
+			want: map[string]IndustryRD{
+				"Semiconductor": {RD: 500, Revenue: 2000, Intensity: 0.25},
+			},
+		},
+		{
+			name: "intensity only",
+			rows: [][]interface{}{
+				{"Industry Name", "R&D/Sales"},
+				{"Retail (General)", 0.01},
+			},
+			want: map[string]IndustryRD{
+				"Retail (General)": {Intensity: 0.01},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDamodaranRD(xlsxWorkbook(t, tt.rows))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDamodaranRD = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseDamodaranRD([]byte("Industry,R&D/Sales\n")); err == nil {
+		t.Error("parsed a CSV file as a workbook")
+	}
+}
+
+func TestSectorRD(t *testing.T) {
+	industries := map[string]IndustryRD{
+		"Software":      {RD: 10, Revenue: 100, Intensity: 0.1},
+		"Semiconductor": {RD: 90, Revenue: 300, Intensity: 0.3},
+		"Hardware":      {Revenue: 200, Intensity: 0.05},
+		"Biotech":       {Intensity: 0.05},
+	}
+	u := config.SectorUniverse{Sectors: []config.SectorDefinition{
+		{Name: "Revenue weighted", DamodaranIndustries: []string{"Software", "Semiconductor"}},
+		{Name: "R&D from intensity", DamodaranIndustries: []string{"Software", "Hardware"}},
+		{Name: "Equal weighted", DamodaranIndustries: []string{"Software", "Biotech", "Unknown"}},
+		{Name: "Unmatched", DamodaranIndustries: []string{"Unknown"}},
+	}}
+
+	want := map[string]float64{
+		"Revenue weighted":   0.25,       // (10 + 90) / (100 + 300)
+		"R&D from intensity": 20.0 / 300, // (10 + 0.05*200) / (100 + 200)
+		"Equal weighted":     0.075,      // Biotech has no revenue: (0.1 + 0.05) / 2
+		"Unmatched":          0,
+	}
+	got := sectorRD(u, industries)
+	for sector, w := range want {
+		if math.Abs(got[sector]-w) > 1e-12 {
+			t.Errorf("%s: R&D intensity = %g, want %g", sector, got[sector], w)
+		}
+	}
+}
@@ -2,69 +2,213 @@
 package data
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/extrame/xls"
 	"sector-analyzer/config"
 )
 
-// FetchSectorPrices retrieves historical price data for all sector ETFs.
+// FetchSectorPrices retrieves historical price data for all sector ETFs
+// in the default universe.
 func FetchSectorPrices(period string) (SectorPrices, error) {
-	cacheKey := GenerateKey("yfinance", map[string]interface{}{"type": "sector_prices", "period": period})
+	return fetchSectorPrices(config.DefaultUniverse(), period, nil)
+}
+
+// fetchSectorPrices retrieves a universe's ETF history, reporting each ticker
+// to progress. The universe benchmark is stored under "_benchmark".
+func fetchSectorPrices(u config.SectorUniverse, period string, progress ProgressFunc) (SectorPrices, error) {
+	cacheKey := GenerateKey("yfinance", map[string]interface{}{
+		"type":     "sector_prices",
+		"period":   period,
+		"universe": u.Name,
+	})
 	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		report(progress, SourceYahooPrices, "", StageProgress, "Using cached sector prices")
 		return cached.(SectorPrices), nil
 	}
 
 	prices := make(SectorPrices)
 
 	// Fetch all sector ETFs
-	for sector, ticker := range config.SectorETFs {
-		series, err := fetchYahooHistory(ticker, period)
+	for _, def := range u.Sectors {
+		sector, ticker := def.Name, def.ETF
+		series, fetched, err := updatePriceHistory(ticker, period, progress)
 		if err != nil {
-			fmt.Printf("Error fetching %s (%s): %v\n", sector, ticker, err)
+			report(progress, SourceYahooPrices, ticker, StageFailed,
+				fmt.Sprintf("Error fetching %s (%s): %v", sector, ticker, err))
 			continue
 		}
 		prices[sector] = series
+		report(progress, SourceYahooPrices, ticker, StageProgress,
+			fmt.Sprintf("Fetched %d bars for %s (%s), %d bars total", fetched, sector, ticker, len(series)))
 	}
 
 	// Fetch benchmark
-	benchmarkSeries, err := fetchYahooHistory(config.MarketBenchmark, period)
+	benchmarkSeries, fetched, err := updatePriceHistory(u.Benchmark, period, progress)
 	if err == nil {
 		prices["_benchmark"] = benchmarkSeries
+		report(progress, SourceYahooPrices, u.Benchmark, StageProgress,
+			fmt.Sprintf("Fetched %d bars for benchmark (%s), %d bars total", fetched, u.Benchmark, len(benchmarkSeries)))
+	} else {
+		report(progress, SourceYahooPrices, u.Benchmark, StageFailed,
+			fmt.Sprintf("Error fetching benchmark (%s): %v", u.Benchmark, err))
+	}
+
+	// Don't cache a refresh where every ticker failed
+	if len(prices) == 0 {
+		return prices, fmt.Errorf("no price data returned for any ticker")
 	}
 
 	GlobalCache.Set(cacheKey, prices)
 	return prices, nil
 }
 
-// fetchYahooHistory retrieves historical data from Yahoo Finance.
-func fetchYahooHistory(ticker string, period string) (PriceSeries, error) {
-	// Calculate time range
+// FetchPriceHistory returns a universe's full stored ETF history from start
+// onwards, unlike the five-year window FetchUniverseData scores. History the
+// store doesn't reach back to is fetched and stored. Prices are validated
+// and converted as FetchUniverseData's are.
+func FetchPriceHistory(u config.SectorUniverse, start time.Time) (SectorPrices, error) {
+	cacheKey := GenerateKey("yfinance", map[string]interface{}{
+		"type":     "price_history",
+		"start":    start.Format("2006-01-02"),
+		"universe": u.Name,
+	})
+	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		return cached.(SectorPrices), nil
+	}
+
+	tickers := map[string]string{"_benchmark": u.Benchmark}
+	for _, def := range u.Sectors {
+		tickers[def.Name] = def.ETF
+	}
+
+	prices := make(SectorPrices)
+	for key, ticker := range tickers {
+		series, err := loadPriceHistory(ticker, start)
+		if err != nil {
+			report(nil, SourceYahooPrices, ticker, StageFailed,
+				fmt.Sprintf("Error fetching history of %s: %v", ticker, err))
+			continue
+		}
+		prices[key] = series
+	}
+	if len(prices) == 0 {
+		return prices, fmt.Errorf("no price data returned for any ticker")
+	}
+
+	prices = validateUniversePrices(u, prices, make(DataIssues), nil)
+	if u.NeedsConversion() {
+		converted, err := convertUniversePrices(u, prices, nil)
+		if err != nil {
+			return nil, err
+		}
+		prices = converted
+	}
+
+	GlobalCache.Set(cacheKey, prices)
+	return prices, nil
+}
+
+// loadPriceHistory returns a ticker's stored bars from start onwards. If
+// the store doesn't reach back to start, the history from start is fetched
+// and replaces the stored record.
+func loadPriceHistory(ticker string, start time.Time) (PriceSeries, error) {
+	rec, ok, err := GlobalStore.LoadPrices(ticker)
+	if err == nil && ok && len(rec.Bars) > 0 && !rec.From.After(start) && sessionDated(rec.Bars) {
+		return priceWindow(rec.Bars, start), nil
+	}
+
+	end := time.Now()
+	full, err := fetchYahooHistory(ticker, start, end)
+	if err != nil {
+		return nil, err
+	}
+	rec = PriceRecord{Ticker: ticker, From: start, Bars: full, UpdatedAt: end}
+	if err := GlobalStore.SavePrices(rec); err != nil {
+		report(nil, SourceYahooPrices, ticker, StageWarning,
+			fmt.Sprintf("Could not store history for %s: %v", ticker, err))
+	}
+	return full, nil
+}
+
+// updatePriceHistory returns a ticker's history for period, requesting only
+// the bars after those already stored. It also returns how many bars were
+// fetched. A new dividend or split re-bases the adjusted history, so it
+// triggers a full refetch, as do bars stored before they were session dated.
+func updatePriceHistory(ticker string, period string, progress ProgressFunc) (PriceSeries, int, error) {
 	end := time.Now()
-	var start time.Time
+	start := periodStart(period, end)
+
+	rec, ok, err := GlobalStore.LoadPrices(ticker)
+	if err != nil {
+		report(progress, SourceYahooPrices, ticker, StageWarning,
+			fmt.Sprintf("Ignoring stored history for %s: %v", ticker, err))
+		ok = false
+	}
+
+	var series PriceSeries
+	var fetched int
+	if ok && len(rec.Bars) > 0 && !rec.From.After(start) && sessionDated(rec.Bars) {
+		dates := make([]time.Time, len(rec.Bars))
+		for i, bar := range rec.Bars {
+			dates[i] = bar.Date
+		}
+		fresh, err := fetchYahooHistory(ticker, revisionStart(dates), end)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !adjustmentsChanged(rec.Bars, fresh) {
+			series = mergePriceSeries(rec.Bars, fresh)
+			fetched = len(fresh)
+		}
+	}
+
+	if series == nil {
+		full, err := fetchYahooHistory(ticker, start, end)
+		if err != nil {
+			return nil, 0, err
+		}
+		series = full
+		fetched = len(full)
+		rec.From = start
+	}
+
+	rec.Ticker = ticker
+	rec.Bars = series
+	rec.UpdatedAt = end
+	if err := GlobalStore.SavePrices(rec); err != nil {
+		report(progress, SourceYahooPrices, ticker, StageWarning,
+			fmt.Sprintf("Could not store history for %s: %v", ticker, err))
+	}
+
+	return priceWindow(series, start), fetched, nil
+}
+
+// periodStart converts a Yahoo-style period ("1y", "2y", "5y") to a start date.
+func periodStart(period string, end time.Time) time.Time {
 	switch period {
 	case "1y":
-		start = end.AddDate(-1, 0, 0)
+		return end.AddDate(-1, 0, 0)
 	case "2y":
-		start = end.AddDate(-2, 0, 0)
-	case "5y":
-		start = end.AddDate(-5, 0, 0)
+		return end.AddDate(-2, 0, 0)
 	default:
-		start = end.AddDate(-5, 0, 0)
+		return end.AddDate(-5, 0, 0)
 	}
+}
 
+// fetchYahooHistory retrieves daily bars between start and end from Yahoo Finance.
+func fetchYahooHistory(ticker string, start, end time.Time) (PriceSeries, error) {
 	// Build Yahoo Finance API URL
 	apiURL := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d&includePrePost=false",
+		"https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d&includePrePost=false&events=div%%7Csplit",
 		url.PathEscape(ticker),
 		start.Unix(),
 		end.Unix(),
@@ -108,6 +252,24 @@ func fetchYahooHistory(ticker string, period string) (PriceSeries, error) {
 
 	quote := result.Indicators.Quote[0]
 	timestamps := result.Timestamp
+	loc := exchangeLocation(result.Meta.ExchangeTimezoneName, result.Meta.GMTOffset)
+
+	var adjClose []float64
+	if len(result.Indicators.AdjClose) > 0 {
+		adjClose = result.Indicators.AdjClose[0].AdjClose
+	}
+
+	// Index dividend and split events by ex-date
+	dividends := make(map[time.Time]float64)
+	for _, div := range result.Events.Dividends {
+		dividends[sessionDate(div.Date, loc)] += div.Amount
+	}
+	splits := make(map[time.Time]float64)
+	for _, split := range result.Events.Splits {
+		if split.Denominator > 0 {
+			splits[sessionDate(split.Date, loc)] = split.Numerator / split.Denominator
+		}
+	}
 
 	var series PriceSeries
 	for i, ts := range timestamps {
@@ -116,9 +278,14 @@ func fetchYahooHistory(ticker string, period string) (PriceSeries, error) {
 		}
 
 		bar := PriceBar{
-			Date:  time.Unix(ts, 0),
+			Date:  sessionDate(ts, loc),
 			Close: quote.Close[i],
 		}
+		if i < len(adjClose) {
+			bar.AdjClose = adjClose[i]
+		}
+		bar.Dividend = dividends[bar.Date]
+		bar.SplitRatio = splits[bar.Date]
 		if i < len(quote.Open) {
 			bar.Open = quote.Open[i]
 		}
@@ -135,9 +302,58 @@ func fetchYahooHistory(ticker string, period string) (PriceSeries, error) {
 		series = append(series, bar)
 	}
 
+	// Without Yahoo's adjusted closes, derive them from the dividend events
+	if len(adjClose) == 0 {
+		adjustForDividends(series)
+	}
+
 	return series, nil
 }
 
+// exchangeLocation returns the time zone of a chart's exchange, from its
+// IANA name or, failing that, its current offset from UTC in seconds.
+func exchangeLocation(name string, gmtOffset int) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(name, gmtOffset)
+}
+
+// sessionDate dates a Yahoo timestamp by its session: noon UTC on its date
+// in the exchange's zone. Yahoo stamps daily bars at the session open, which
+// for Sydney or Auckland falls on the previous day in UTC; noon UTC is on
+// the session's date in UTC and New York alike, so truncateDay and the
+// calendars agree on it.
+func sessionDate(ts int64, loc *time.Location) time.Time {
+	y, m, d := time.Unix(ts, 0).In(loc).Date()
+	return time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+}
+
+// sessionDated reports whether stored bars are dated by sessionDate. Bars
+// stored before they were carry the open's timestamp and are refetched.
+func sessionDated(bars PriceSeries) bool {
+	if len(bars) == 0 {
+		return true
+	}
+	t := bars[0].Date.UTC()
+	return t.Hour() == 12 && t.Minute() == 0 && t.Second() == 0
+}
+
+// adjustForDividends fills AdjClose by back-adjusting Close for each cash
+// dividend, the same way Yahoo computes adjusted closes. Close is already
+// split-adjusted, so only dividends need handling.
+func adjustForDividends(series PriceSeries) {
+	factor := 1.0
+	for i := len(series) - 1; i >= 0; i-- {
+		series[i].AdjClose = series[i].Close * factor
+		if series[i].Dividend > 0 && i > 0 && series[i-1].Close > 0 {
+			factor *= 1 - series[i].Dividend/series[i-1].Close
+		}
+	}
+}
+
 // yahooCrumb holds a reusable cookie+crumb pair for Yahoo Finance API auth.
 type yahooCrumb struct {
 	cookies []*http.Cookie
@@ -196,35 +412,50 @@ func getYahooCrumb() (*yahooCrumb, error) {
 	return &yahooCrumb{cookies: cookies, crumb: crumb}, nil
 }
 
-// FetchSectorInfo retrieves current info (P/E, etc.) for all sector ETFs.
+// FetchSectorInfo retrieves current info (P/E, etc.) for all sector ETFs
+// in the default universe.
 func FetchSectorInfo() (map[string]SectorInfo, error) {
-	cacheKey := GenerateKey("yfinance", map[string]interface{}{"type": "sector_info"})
+	info, _ := fetchSectorInfo(config.DefaultUniverse(), nil)
+	return info, nil
+}
+
+// fetchSectorInfo retrieves ETF info, reporting each ticker to progress.
+// When Yahoo authentication fails it returns empty info for every sector
+// together with the authentication error.
+func fetchSectorInfo(u config.SectorUniverse, progress ProgressFunc) (map[string]SectorInfo, error) {
+	cacheKey := GenerateKey("yfinance", map[string]interface{}{"type": "sector_info", "universe": u.Name})
 	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		report(progress, SourceYahooInfo, "", StageProgress, "Using cached sector info")
 		return cached.(map[string]SectorInfo), nil
 	}
 
 	// Get cookie+crumb for authenticated requests
 	auth, err := getYahooCrumb()
 	if err != nil {
-		fmt.Printf("Warning: Could not authenticate with Yahoo Finance: %v\n", err)
-		fmt.Println("Sector P/E data will be unavailable.")
+		report(progress, SourceYahooInfo, "", StageWarning,
+			fmt.Sprintf("Warning: Could not authenticate with Yahoo Finance: %v", err))
+		report(progress, SourceYahooInfo, "", StageWarning, "Sector P/E data will be unavailable.")
 		info := make(map[string]SectorInfo)
-		for sector := range config.SectorETFs {
-			info[sector] = SectorInfo{}
+		for _, def := range u.Sectors {
+			info[def.Name] = SectorInfo{}
 		}
-		return info, nil
+		return info, err
 	}
 
 	info := make(map[string]SectorInfo)
 
-	for sector, ticker := range config.SectorETFs {
+	for _, def := range u.Sectors {
+		sector, ticker := def.Name, def.ETF
 		sectorInfo, err := fetchYahooInfo(ticker, auth)
 		if err != nil {
-			fmt.Printf("Error fetching info for %s (%s): %v\n", sector, ticker, err)
+			report(progress, SourceYahooInfo, ticker, StageFailed,
+				fmt.Sprintf("Error fetching info for %s (%s): %v", sector, ticker, err))
 			info[sector] = SectorInfo{}
 			continue
 		}
 		info[sector] = sectorInfo
+		report(progress, SourceYahooInfo, ticker, StageProgress,
+			fmt.Sprintf("Fetched info for %s (%s)", sector, ticker))
 		// Small delay to avoid rate limiting
 		time.Sleep(200 * time.Millisecond)
 	}
@@ -233,17 +464,20 @@ func FetchSectorInfo() (map[string]SectorInfo, error) {
 	return info, nil
 }
 
-// fetchYahooInfo retrieves ETF info from Yahoo Finance using cookie+crumb auth.
-func fetchYahooInfo(ticker string, auth *yahooCrumb) (SectorInfo, error) {
+// requestQuoteSummary retrieves the given quoteSummary modules
+// (comma-separated) for a ticker using cookie+crumb auth.
+func requestQuoteSummary(ticker, modules string, auth *yahooCrumb) (YahooQuoteSummary, error) {
+	var quoteSummary YahooQuoteSummary
 	apiURL := fmt.Sprintf(
-		"https://query2.finance.yahoo.com/v10/finance/quoteSummary/%s?modules=summaryDetail,defaultKeyStatistics&crumb=%s",
+		"https://query2.finance.yahoo.com/v10/finance/quoteSummary/%s?modules=%s&crumb=%s",
 		url.PathEscape(ticker),
+		modules,
 		url.QueryEscape(auth.crumb),
 	)
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return SectorInfo{}, err
+		return quoteSummary, err
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 	for _, c := range auth.cookies {
@@ -253,21 +487,27 @@ func fetchYahooInfo(ticker string, auth *yahooCrumb) (SectorInfo, error) {
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return SectorInfo{}, err
+		return quoteSummary, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return SectorInfo{}, fmt.Errorf("yahoo finance returned status %d for %s", resp.StatusCode, ticker)
+		return quoteSummary, fmt.Errorf("yahoo finance returned status %d for %s", resp.StatusCode, ticker)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return SectorInfo{}, err
+		return quoteSummary, err
 	}
 
-	var quoteSummary YahooQuoteSummary
-	if err := json.Unmarshal(body, &quoteSummary); err != nil {
+	err = json.Unmarshal(body, &quoteSummary)
+	return quoteSummary, err
+}
+
+// fetchYahooInfo retrieves ETF info from Yahoo Finance using cookie+crumb auth.
+func fetchYahooInfo(ticker string, auth *yahooCrumb) (SectorInfo, error) {
+	quoteSummary, err := requestQuoteSummary(ticker, "summaryDetail,defaultKeyStatistics,topHoldings", auth)
+	if err != nil {
 		return SectorInfo{}, err
 	}
 
@@ -296,9 +536,83 @@ func fetchYahooInfo(ticker string, auth *yahooCrumb) (SectorInfo, error) {
 		info.ForwardPE = info.TrailingPE
 	}
 
+	// ETFs report their distribution yield as "yield"
 	if result.SummaryDetail.DividendYield.Raw > 0 {
 		dy := result.SummaryDetail.DividendYield.Raw
 		info.DividendYield = &dy
+	} else if result.SummaryDetail.Yield.Raw > 0 {
+		dy := result.SummaryDetail.Yield.Raw
+		info.DividendYield = &dy
+	}
+
+	// The 5-year average is quoted in percent
+	if result.SummaryDetail.FiveYearAvgDividendYield.Raw > 0 {
+		dy := result.SummaryDetail.FiveYearAvgDividendYield.Raw / 100
+		info.DividendYield5y = &dy
+	}
+
+	if result.SummaryDetail.AverageVolume.Raw > 0 {
+		v := int64(result.SummaryDetail.AverageVolume.Raw)
+		info.AvgVolume = &v
+	}
+
+	if result.SummaryDetail.MarketCap.Raw > 0 {
+		mc := result.SummaryDetail.MarketCap.Raw
+		info.MarketCap = &mc
+	}
+
+	if result.SummaryDetail.TotalAssets.Raw > 0 {
+		na := result.SummaryDetail.TotalAssets.Raw
+		info.NetAssets = &na
+	} else if result.DefaultKeyStatistics.TotalAssets.Raw > 0 {
+		na := result.DefaultKeyStatistics.TotalAssets.Raw
+		info.NetAssets = &na
+	}
+
+	// Holdings ratios are reported inverted: earnings, book value and sales
+	// per unit of price. Their quotients give the holdings' return on equity
+	// (E/B) and net margin (E/S).
+	holdings := result.TopHoldings.EquityHoldings
+	earningsYield := holdings.PriceToEarnings.Raw
+	bookYield := holdings.PriceToBook.Raw
+	salesYield := holdings.PriceToSales.Raw
+
+	if bookYield > 0 {
+		pb := 1 / bookYield
+		info.PriceToBook = &pb
+	}
+	if salesYield > 0 {
+		ps := 1 / salesYield
+		info.PriceToSales = &ps
+	}
+	if earningsYield > 0 && bookYield > 0 {
+		roe := earningsYield / bookYield
+		info.ReturnOnEquity = &roe
+	}
+	if earningsYield > 0 && salesYield > 0 {
+		margin := earningsYield / salesYield
+		info.NetMargin = &margin
+	}
+	if earningsYield > 0 && info.TrailingPE == nil {
+		pe := 1 / earningsYield
+		info.TrailingPE = &pe
+		if info.ForwardPE == nil {
+			info.ForwardPE = &pe
+		}
+	}
+
+	if holdings.ThreeYearEarningsGrowth.Raw != 0 {
+		growth := holdings.ThreeYearEarningsGrowth.Raw
+		info.EarningsGrowth = &growth
+	}
+
+	// PEG from Yahoo, or P/E over growth in percent when growth is positive
+	if result.DefaultKeyStatistics.PegRatio.Raw > 0 {
+		peg := result.DefaultKeyStatistics.PegRatio.Raw
+		info.PEGRatio = &peg
+	} else if info.ForwardPE != nil && info.EarningsGrowth != nil && *info.EarningsGrowth > 0 {
+		peg := *info.ForwardPE / (*info.EarningsGrowth * 100)
+		info.PEGRatio = &peg
 	}
 
 	return info, nil
@@ -306,6 +620,12 @@ func fetchYahooInfo(ticker string, auth *yahooCrumb) (SectorInfo, error) {
 
 // FetchFREDSeries retrieves a single FRED time series.
 func FetchFREDSeries(seriesID string, startDate time.Time) (TimeSeries, error) {
+	return fetchFREDSeries(seriesID, startDate, nil)
+}
+
+// fetchFREDSeries retrieves a FRED series, reporting storage failures to
+// progress as warnings.
+func fetchFREDSeries(seriesID string, startDate time.Time, progress ProgressFunc) (TimeSeries, error) {
 	apiKey := os.Getenv("FRED_API_KEY")
 	if apiKey == "" {
 		return TimeSeries{}, fmt.Errorf("FRED_API_KEY not set")
@@ -319,6 +639,35 @@ func FetchFREDSeries(seriesID string, startDate time.Time) (TimeSeries, error) {
 		return cached.(TimeSeries), nil
 	}
 
+	// Only request observations after those already stored
+	rec, ok, err := GlobalStore.LoadSeries(seriesID)
+	if err != nil || !ok || len(rec.Series.Dates) == 0 || rec.From.After(startDate) {
+		rec = SeriesRecord{ID: seriesID, Source: SourceFRED, From: startDate}
+	}
+
+	requestStart := startDate
+	if len(rec.Series.Dates) > 0 {
+		requestStart = revisionStart(rec.Series.Dates)
+	}
+	fresh, err := requestFREDObservations(seriesID, apiKey, requestStart)
+	if err != nil {
+		return TimeSeries{}, err
+	}
+
+	rec.Series = mergeTimeSeries(rec.Series, fresh)
+	rec.UpdatedAt = time.Now()
+	if err := GlobalStore.SaveSeries(rec); err != nil {
+		report(progress, SourceFRED, seriesID, StageWarning,
+			fmt.Sprintf("Warning: Could not store FRED series %s: %v", seriesID, err))
+	}
+
+	ts := seriesWindow(rec.Series, startDate)
+	GlobalCache.Set(cacheKey, ts)
+	return ts, nil
+}
+
+// requestFREDObservations downloads a FRED series from startDate onwards.
+func requestFREDObservations(seriesID, apiKey string, startDate time.Time) (TimeSeries, error) {
 	apiURL := fmt.Sprintf(
 		"https://api.stlouisfed.org/fred/series/observations?series_id=%s&api_key=%s&file_type=json&observation_start=%s",
 		seriesID,
@@ -366,258 +715,344 @@ func FetchFREDSeries(seriesID string, startDate time.Time) (TimeSeries, error) {
 		ts.Values = append(ts.Values, value)
 	}
 
-	GlobalCache.Set(cacheKey, ts)
 	return ts, nil
 }
 
 // FetchMacroData retrieves all FRED macro series.
 func FetchMacroData(yearsBack int) (MacroData, error) {
+	data, _ := fetchMacroData(yearsBack, nil)
+	return data, nil
+}
+
+// fetchMacroData retrieves FRED series, reporting each series to progress.
+// It returns an error only when no series could be fetched.
+func fetchMacroData(yearsBack int, progress ProgressFunc) (MacroData, error) {
 	startDate := time.Now().AddDate(-yearsBack, 0, 0)
 	data := make(MacroData)
 
+	var lastErr error
 	for name, seriesID := range config.FREDSeries {
-		ts, err := FetchFREDSeries(seriesID, startDate)
+		ts, err := fetchFREDSeries(seriesID, startDate, progress)
 		if err != nil {
-			fmt.Printf("Error fetching FRED series %s: %v\n", seriesID, err)
+			report(progress, SourceFRED, seriesID, StageFailed,
+				fmt.Sprintf("Error fetching FRED series %s: %v", seriesID, err))
+			lastErr = err
 			continue
 		}
 		data[name] = ts
+		report(progress, SourceFRED, seriesID, StageProgress,
+			fmt.Sprintf("Fetched %d observations for %s", len(ts.Values), seriesID))
 	}
 
+	if len(data) == 0 && lastErr != nil {
+		return data, lastErr
+	}
 	return data, nil
 }
 
-// FetchBLSEmployment retrieves employment data from BLS.
+// FetchBLSEmployment retrieves employment data from BLS for the default universe.
 func FetchBLSEmployment(yearsBack int) (EmploymentData, error) {
-	cacheKey := GenerateKey("bls", map[string]interface{}{"type": "employment", "years": yearsBack})
+	return fetchBLSEmployment(config.DefaultUniverse(), yearsBack, nil)
+}
+
+// fetchBLSEmployment retrieves employment data for a universe's BLS series.
+// Series that cannot be updated keep their stored history, and BLS messages
+// about a series are reported as warnings for it.
+func fetchBLSEmployment(u config.SectorUniverse, yearsBack int, progress ProgressFunc) (EmploymentData, error) {
+	cacheKey := GenerateKey("bls", map[string]interface{}{"type": "employment", "years": yearsBack, "universe": u.Name})
 	if cached, ok := GlobalCache.Get(cacheKey); ok {
 		return cached.(EmploymentData), nil
 	}
 
 	endYear := time.Now().Year()
 	startYear := endYear - yearsBack
-
-	// Build series IDs list
-	var seriesIDs []string
-	seriesIDToSector := make(map[string]string)
-	for sector, seriesID := range config.BLSEmploymentSeries {
-		seriesIDs = append(seriesIDs, seriesID)
-		seriesIDToSector[seriesID] = sector
-	}
-
-	// BLS API request
-	payload := map[string]interface{}{
-		"seriesid":  seriesIDs,
-		"startyear": strconv.Itoa(startYear),
-		"endyear":   strconv.Itoa(endYear),
-	}
-
-	// Add API key if available
-	if apiKey := os.Getenv("BLS_API_KEY"); apiKey != "" {
-		payload["registrationkey"] = apiKey
-	}
-
-	payloadBytes, _ := json.Marshal(payload)
-	resp, err := http.Post(
-		"https://api.bls.gov/publicAPI/v2/timeseries/data/",
-		"application/json",
-		strings.NewReader(string(payloadBytes)),
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var blsResp BLSResponse
-	if err := json.Unmarshal(body, &blsResp); err != nil {
-		return nil, err
-	}
-
-	if blsResp.Status != "REQUEST_SUCCEEDED" {
-		return nil, fmt.Errorf("BLS API error: %v", blsResp.Message)
+	start := time.Date(startYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// Build series IDs list (a series may be shared by several sectors)
+	seriesIDs := []string{}
+	seriesIDToSectors := make(map[string][]string)
+	for _, def := range u.Sectors {
+		sector, seriesID := def.Name, def.BLSSeries
+		if seriesID == "" {
+			continue
+		}
+		if _, seen := seriesIDToSectors[seriesID]; !seen {
+			seriesIDs = append(seriesIDs, seriesID)
+		}
+		seriesIDToSectors[seriesID] = append(seriesIDToSectors[seriesID], sector)
+	}
+
+	// Series with stored history only need the years holding their last few
+	// months; the rest are requested in full
+	records := make(map[string]SeriesRecord)
+	var fullIDs, updateIDs []string
+	updateYear := endYear
+	for _, id := range seriesIDs {
+		rec, ok, err := GlobalStore.LoadSeries(id)
+		if err != nil || !ok || len(rec.Series.Dates) == 0 || rec.From.After(start) {
+			records[id] = SeriesRecord{ID: id, Source: SourceBLS, From: start}
+			fullIDs = append(fullIDs, id)
+			continue
+		}
+		records[id] = rec
+		updateIDs = append(updateIDs, id)
+		if y := revisionStart(rec.Series.Dates).Year(); y < updateYear {
+			updateYear = y
+		}
 	}
 
-	data := make(EmploymentData)
-	for _, series := range blsResp.Results.Series {
-		sector, ok := seriesIDToSector[series.SeriesID]
-		if !ok {
+	fetched := make(map[string]TimeSeries)
+	var lastErr error
+	for _, req := range []struct {
+		ids       []string
+		startYear int
+	}{{fullIDs, startYear}, {updateIDs, updateYear}} {
+		if len(req.ids) == 0 {
 			continue
 		}
-
-		var ts TimeSeries
-		for _, item := range series.Data {
-			year, _ := strconv.Atoi(item.Year)
-			monthStr := strings.TrimPrefix(item.Period, "M")
-			month, _ := strconv.Atoi(monthStr)
-
-			if month < 1 || month > 12 {
-				continue
+		result, err := requestBLSSeries(req.ids, req.startYear, endYear, progress)
+		if err != nil {
+			report(progress, SourceBLS, "", StageFailed, fmt.Sprintf("Error fetching BLS series: %v", err))
+			lastErr = err
+			continue
+		}
+		for id, messages := range result.Warnings {
+			for _, msg := range messages {
+				report(progress, SourceBLS, id, StageWarning, msg)
 			}
+		}
+		for id, ts := range result.Series {
+			fetched[id] = ts
+		}
+	}
 
-			date := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-			value, err := strconv.ParseFloat(item.Value, 64)
-			if err != nil {
-				continue
+	data := make(EmploymentData)
+	for _, id := range seriesIDs {
+		rec := records[id]
+		if fresh, ok := fetched[id]; ok {
+			rec.Series = mergeTimeSeries(rec.Series, fresh)
+			rec.UpdatedAt = time.Now()
+			if err := GlobalStore.SaveSeries(rec); err != nil {
+				report(progress, SourceBLS, id, StageWarning,
+					fmt.Sprintf("Warning: Could not store BLS series %s: %v", id, err))
 			}
-
-			ts.Dates = append(ts.Dates, date)
-			ts.Values = append(ts.Values, value)
 		}
-
-		// Sort by date (BLS returns newest first)
-		sortTimeSeries(&ts)
-		data[sector] = ts
+		if len(rec.Series.Dates) == 0 {
+			continue
+		}
+		ts := seriesWindow(rec.Series, start)
+		for _, sector := range seriesIDToSectors[id] {
+			data[sector] = ts
+		}
 	}
 
-	GlobalCache.Set(cacheKey, data)
+	if len(data) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	if lastErr == nil {
+		GlobalCache.Set(cacheKey, data)
+	}
 	return data, nil
 }
 
-// sortTimeSeries sorts a time series by date ascending.
+// sortTimeSeries sorts a time series by date ascending, keeping Values and
+// Preliminary aligned with Dates.
 func sortTimeSeries(ts *TimeSeries) {
-	// Simple bubble sort for small arrays
 	n := len(ts.Dates)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if ts.Dates[j].After(ts.Dates[j+1]) {
-				ts.Dates[j], ts.Dates[j+1] = ts.Dates[j+1], ts.Dates[j]
-				ts.Values[j], ts.Values[j+1] = ts.Values[j+1], ts.Values[j]
-			}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return ts.Dates[order[a]].Before(ts.Dates[order[b]]) })
+
+	dates := make([]time.Time, n)
+	values := make([]float64, n)
+	for i, j := range order {
+		dates[i], values[i] = ts.Dates[j], ts.Values[j]
+	}
+	ts.Dates, ts.Values = dates, values
+	if ts.Preliminary != nil {
+		preliminary := make([]bool, n)
+		for i, j := range order {
+			preliminary[i] = ts.Preliminary[j]
 		}
+		ts.Preliminary = preliminary
 	}
 }
 
-// FetchDamodaranRD fetches R&D intensity data from Damodaran's Excel file.
+// FetchDamodaranRD fetches R&D intensity data from Damodaran's Excel file
+// for the default universe.
 func FetchDamodaranRD() (RDData, error) {
-	cacheKey := GenerateKey("damodaran", map[string]interface{}{"type": "rd_intensity"})
+	return fetchDamodaranRD(config.DefaultUniverse(), nil)
+}
+
+// fetchDamodaranRD aggregates Damodaran industry R&D into a universe's sectors,
+// reporting a fallback to defaults as a warning.
+func fetchDamodaranRD(u config.SectorUniverse, progress ProgressFunc) (RDData, error) {
+	cacheKey := GenerateKey("damodaran", map[string]interface{}{"type": "rd_intensity", "universe": u.Name})
 	if cached, ok := GlobalCache.Get(cacheKey); ok {
 		return cached.(RDData), nil
 	}
 
 	// Try to fetch and parse live data
-	data, err := fetchDamodaranExcel()
+	var data RDData
+	industries, err := fetchDamodaranIndustries(progress)
+	if err == nil {
+		data, err = aggregateRD(u, industries, progress)
+	}
 	if err != nil {
-		fmt.Printf("Warning: Could not fetch Damodaran data: %v. Using defaults.\n", err)
+		report(progress, SourceDamodaran, "", StageWarning,
+			fmt.Sprintf("Warning: Could not fetch Damodaran data: %v. Using defaults.", err))
 		// Fallback to defaults
-		data = getDefaultRDData()
+		data = defaultRDForUniverse(u)
 	}
 
 	GlobalCache.Set(cacheKey, data)
 	return data, nil
 }
 
-// fetchDamodaranExcel downloads and parses the Damodaran R&D Excel file (old .xls format).
-func fetchDamodaranExcel() (RDData, error) {
-	resp, err := http.Get(config.DamodaranRDURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d from Damodaran", resp.StatusCode)
-	}
-
-	// Read the Excel file into memory
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+// fetchDamodaranIndustries returns the R&D figures of each Damodaran
+// industry. The parsed sheet is cached so each universe reuses one download.
+// A fallback to the stored dataset is reported to progress as a warning.
+func fetchDamodaranIndustries(progress ProgressFunc) (map[string]IndustryRD, error) {
+	cacheKey := GenerateKey("damodaran", map[string]interface{}{"type": "industry_rd"})
+	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		return cached.(map[string]IndustryRD), nil
 	}
 
-	// Parse with xls library (supports old .xls format)
-	xlsFile, err := xls.OpenReader(bytes.NewReader(body), "utf-8")
+	industries, err := fetchDamodaranExcel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Excel: %w", err)
-	}
-
-	// Get sheet 1 ("Industry Averages") - sheet 0 is "Variables & FAQ"
-	sheet := xlsFile.GetSheet(1)
-	if sheet == nil {
-		return nil, fmt.Errorf("Industry Averages sheet not found")
-	}
-
-	// Aggregate R&D by GICS sector
-	sectorRD := make(map[string][]float64)
-	for sector := range config.SectorETFs {
-		sectorRD[sector] = []float64{}
+		// Fall back to the last dataset that parsed successfully
+		rec, ok, loadErr := GlobalStore.LoadIndustryRD()
+		if loadErr != nil || !ok {
+			return nil, err
+		}
+		report(progress, SourceDamodaran, "", StageWarning,
+			fmt.Sprintf("Warning: Could not fetch Damodaran data: %v. Using stored data from %s.",
+				err, rec.FetchedAt.Format("2006-01-02")))
+		industries = rec.Industries
+	} else if err := GlobalStore.SaveIndustryRD(IndustryRDRecord{Industries: industries, FetchedAt: time.Now()}); err != nil {
+		report(progress, SourceDamodaran, "", StageWarning,
+			fmt.Sprintf("Warning: Could not store Damodaran data: %v", err))
 	}
 
-	// Column 0 = Industry Name, Column 5 = "Current R&D as % of Revenue"
-	industryCol := 0
-	rdSalesCol := 5
-	dataStartRow := 8 // Row 7 is headers, data starts at row 8
+	GlobalCache.Set(cacheKey, industries)
+	return industries, nil
+}
 
-	// Iterate through rows
-	maxRow := int(sheet.MaxRow)
-	for i := dataStartRow; i <= maxRow; i++ {
-		row := sheet.Row(i)
-		if row == nil {
-			continue
-		}
+// aggregateRD aggregates industry R&D into the universe's sectors, failing
+// if too few sectors have data. Each sector's intensity is reported to progress.
+func aggregateRD(u config.SectorUniverse, industries map[string]IndustryRD, progress ProgressFunc) (RDData, error) {
+	result := sectorRD(u, industries)
 
-		// Get industry name
-		industry := strings.TrimSpace(row.Col(industryCol))
-		if industry == "" {
-			continue
+	// Verify we got meaningful data
+	nonZeroCount := 0
+	for sector, v := range result {
+		if v > 0 {
+			report(progress, SourceDamodaran, "", StageProgress, fmt.Sprintf("  %s: %.2f%%", sector, v*100))
+			nonZeroCount++
 		}
+	}
 
-		// Get R&D as % of Revenue value
-		rdValueStr := strings.TrimSpace(row.Col(rdSalesCol))
-		if rdValueStr == "" {
-			continue
-		}
+	if nonZeroCount < min(3, len(u.Sectors)) {
+		return nil, fmt.Errorf("insufficient R&D data extracted (only %d sectors)", nonZeroCount)
+	}
 
-		// Parse R&D value (already as decimal, e.g., 0.15 = 15%)
-		rdValue, err := strconv.ParseFloat(rdValueStr, 64)
-		if err != nil {
-			continue
-		}
+	report(progress, SourceDamodaran, "", StageProgress,
+		fmt.Sprintf("Successfully parsed Damodaran R&D data for %d sectors", nonZeroCount))
+	return result, nil
+}
 
-		// Skip invalid values
-		if rdValue < 0 || rdValue > 1 {
-			continue
+// sectorRD aggregates industry R&D into the universe's sectors as total R&D
+// over total revenue, so large industries weigh more than niche ones. Sectors
+// with an industry lacking dollar figures (older datasets and stored records)
+// fall back to the equal-weighted mean of intensities; sectors with no
+// matching industries get 0.
+func sectorRD(u config.SectorUniverse, industries map[string]IndustryRD) RDData {
+	result := make(RDData)
+	for _, def := range u.Sectors {
+		var rd, revenue, sum float64
+		var n int
+		weighted := true
+		for _, industry := range def.DamodaranIndustries {
+			rec, ok := industries[industry]
+			if !ok {
+				continue
+			}
+			n++
+			sum += rec.Intensity
+			if rec.Revenue <= 0 {
+				weighted = false
+				continue
+			}
+			// Industries without an R&D dollar column carry it through revenue
+			if rec.RD > 0 {
+				rd += rec.RD
+			} else {
+				rd += rec.Intensity * rec.Revenue
+			}
+			revenue += rec.Revenue
 		}
 
-		// Map to GICS sector
-		if gicsSector, ok := config.DamodaranToGICS[industry]; ok {
-			sectorRD[gicsSector] = append(sectorRD[gicsSector], rdValue)
+		switch {
+		case n == 0:
+			result[def.Name] = 0.0
+		case weighted:
+			result[def.Name] = rd / revenue
+		default:
+			result[def.Name] = sum / float64(n)
 		}
 	}
+	return result
+}
 
-	// Calculate averages
-	result := make(RDData)
-	for sector, values := range sectorRD {
-		if len(values) > 0 {
-			var sum float64
-			for _, v := range values {
-				sum += v
+// damodaranCoverage compares a dataset's industries with those the universe
+// assigns to its sectors, so config.DamodaranToGICS and custom universes can
+// be kept in step with Damodaran's industry names. Market totals are ignored.
+func damodaranCoverage(u config.SectorUniverse, industries map[string]IndustryRD) IndustryCoverage {
+	assigned := make(map[string]bool)
+	coverage := IndustryCoverage{Unmapped: []string{}, Missing: []string{}}
+	for _, def := range u.Sectors {
+		for _, industry := range def.DamodaranIndustries {
+			if assigned[industry] {
+				continue
+			}
+			assigned[industry] = true
+			if _, ok := industries[industry]; !ok {
+				coverage.Missing = append(coverage.Missing, industry)
 			}
-			result[sector] = sum / float64(len(values))
-		} else {
-			result[sector] = 0.0
 		}
 	}
-
-	// Verify we got meaningful data
-	nonZeroCount := 0
-	for sector, v := range result {
-		if v > 0 {
-			fmt.Printf("  %s: %.2f%%\n", sector, v*100)
-			nonZeroCount++
+	for industry := range industries {
+		if !assigned[industry] && !strings.HasPrefix(strings.ToLower(industry), "total market") {
+			coverage.Unmapped = append(coverage.Unmapped, industry)
 		}
 	}
+	sort.Strings(coverage.Unmapped)
+	sort.Strings(coverage.Missing)
+	return coverage
+}
 
-	if nonZeroCount < 3 {
-		return nil, fmt.Errorf("insufficient R&D data extracted (only %d sectors)", nonZeroCount)
+// fetchIndustryCoverage reports the universe's Damodaran industry coverage
+// as warnings. It returns nil when no dataset is available.
+func fetchIndustryCoverage(u config.SectorUniverse, progress ProgressFunc) *IndustryCoverage {
+	industries, err := fetchDamodaranIndustries(progress)
+	if err != nil {
+		return nil
 	}
 
-	fmt.Printf("Successfully parsed Damodaran R&D data for %d sectors\n", nonZeroCount)
-	return result, nil
+	coverage := damodaranCoverage(u, industries)
+	if len(coverage.Unmapped) > 0 {
+		report(progress, SourceDamodaran, "", StageWarning,
+			fmt.Sprintf("%d Damodaran industries are not assigned to a sector: %s",
+				len(coverage.Unmapped), strings.Join(coverage.Unmapped, "; ")))
+	}
+	if len(coverage.Missing) > 0 {
+		report(progress, SourceDamodaran, "", StageWarning,
+			fmt.Sprintf("%d assigned industries are not in the Damodaran dataset: %s",
+				len(coverage.Missing), strings.Join(coverage.Missing, "; ")))
+	}
+	return &coverage
 }
 
 // getDefaultRDData returns fallback R&D values based on historical Damodaran averages.
@@ -637,29 +1072,136 @@ func getDefaultRDData() RDData {
 	}
 }
 
+// defaultRDForUniverse maps the GICS defaults onto a universe. Sectors not named
+// after a GICS sector take the average default of the GICS sectors their
+// industries belong to in the built-in universe, whatever the sector config.
+func defaultRDForUniverse(u config.SectorUniverse) RDData {
+	defaults := getDefaultRDData()
+	result := make(RDData)
+
+	gicsSector := make(map[string]string)
+	for _, def := range config.DefaultSectorUniverse().Sectors {
+		for _, industry := range def.DamodaranIndustries {
+			gicsSector[industry] = def.Name
+		}
+	}
+
+	for _, def := range u.Sectors {
+		if v, ok := defaults[def.Name]; ok {
+			result[def.Name] = v
+			continue
+		}
+
+		var sum float64
+		var n int
+		for _, industry := range def.DamodaranIndustries {
+			if gics, ok := gicsSector[industry]; ok {
+				sum += defaults[gics]
+				n++
+			}
+		}
+		if n > 0 {
+			result[def.Name] = sum / float64(n)
+		}
+	}
+
+	return result
+}
+
 // FetchAllData retrieves all data needed for sector analysis.
 func FetchAllData() (*AllData, error) {
-	fmt.Println("Fetching sector price data...")
-	sectorPrices, _ := FetchSectorPrices("5y")
+	return FetchAllDataWithProgress(nil)
+}
 
-	fmt.Println("Fetching sector info...")
-	sectorInfo, _ := FetchSectorInfo()
+// FetchAllDataWithProgress retrieves all data for the default universe,
+// reporting each source and ticker to progress.
+func FetchAllDataWithProgress(progress ProgressFunc) (*AllData, error) {
+	return FetchUniverseData(config.DefaultUniverse(), progress)
+}
 
-	fmt.Println("Fetching macro data from FRED...")
-	macroData, _ := FetchMacroData(config.MacroSensitivityYears)
+// FetchUniverseData retrieves all data needed to score a universe, reporting
+// each source and ticker to progress. Sources that could not be fetched are
+// recorded in AllData.FailedSources; the returned error is always nil so
+// that partial data can still be scored.
+func FetchUniverseData(u config.SectorUniverse, progress ProgressFunc) (*AllData, error) {
+	failed := make(map[string]string)
+	finish := func(source string, err error) {
+		if err != nil {
+			failed[source] = err.Error()
+			report(progress, source, "", StageFailed, fmt.Sprintf("%s failed: %v", source, err))
+			return
+		}
+		report(progress, source, "", StageCompleted, source+" completed")
+	}
 
-	fmt.Println("Fetching employment data from BLS...")
-	employmentData, _ := FetchBLSEmployment(5)
+	report(progress, SourceYahooPrices, "", StageStarted, "Fetching sector price data...")
+	sectorPrices, err := fetchSectorPrices(u, "5y", progress)
+	finish(SourceYahooPrices, err)
 
-	fmt.Println("Fetching R&D data...")
-	rdData, _ := FetchDamodaranRD()
+	issues := make(DataIssues)
+	sectorPrices = validateUniversePrices(u, sectorPrices, issues, progress)
+
+	// Convert before any return or relative strength is calculated
+	currency := u.Currency
+	if u.NeedsConversion() && len(sectorPrices) > 0 {
+		report(progress, SourceFX, "", StageStarted, "Converting prices to "+u.ConvertTo+"...")
+		sectorPrices, err = convertUniversePrices(u, sectorPrices, progress)
+		finish(SourceFX, err)
+		currency = u.ConvertTo
+	}
+
+	report(progress, SourceYahooInfo, "", StageStarted, "Fetching sector info...")
+	sectorInfo, err := fetchSectorInfo(u, progress)
+	finish(SourceYahooInfo, err)
+
+	report(progress, SourceFRED, "", StageStarted, "Fetching macro data from FRED...")
+	macroData, err := fetchMacroData(config.MacroSensitivityYears, progress)
+	finish(SourceFRED, err)
+	macroData = validateMacroData(macroData, issues, progress)
+
+	report(progress, SourceBLS, "", StageStarted, "Fetching employment data from BLS...")
+	employmentData, err := fetchBLSEmployment(u, 5, progress)
+	finish(SourceBLS, err)
+	employmentData = validateEmploymentData(u, employmentData, issues, progress)
+
+	report(progress, SourceDamodaran, "", StageStarted, "Fetching R&D data...")
+	rdData, err := fetchDamodaranRD(u, progress)
+	finish(SourceDamodaran, err)
+	rdHistory := fetchRDHistory(u, rdData, progress)
+	coverage := fetchIndustryCoverage(u, progress)
+
+	var holdings map[string][]Holding
+	var lookThrough map[string]LookThrough
+	if config.HoldingsDir != "" {
+		report(progress, SourceHoldings, "", StageStarted, "Loading ETF holdings...")
+		holdings, lookThrough, err = fetchLookThrough(u, progress)
+		finish(SourceHoldings, err)
+	}
+
+	var constituentPrices map[string]PriceSeries
+	if len(holdings) > 0 {
+		report(progress, SourceConstituents, "", StageStarted, "Fetching constituent prices...")
+		constituentPrices, err = fetchConstituentPrices(holdings, progress)
+		finish(SourceConstituents, err)
+	}
 
 	return &AllData{
-		SectorPrices:   sectorPrices,
-		SectorInfo:     sectorInfo,
-		MacroData:      macroData,
-		EmploymentData: employmentData,
-		RDData:         rdData,
-		FetchedAt:      time.Now(),
+		Universe:          u.Name,
+		Benchmark:         u.Benchmark,
+		Currency:          currency,
+		Sectors:           u.SectorNames(),
+		SectorPrices:      sectorPrices,
+		SectorInfo:        sectorInfo,
+		MacroData:         macroData,
+		EmploymentData:    employmentData,
+		RDData:            rdData,
+		RDHistory:         rdHistory,
+		Industries:        coverage,
+		Holdings:          holdings,
+		LookThrough:       lookThrough,
+		ConstituentPrices: constituentPrices,
+		FailedSources:     failed,
+		Issues:            issues,
+		FetchedAt:         time.Now(),
 	}, nil
 }
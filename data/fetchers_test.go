@@ -0,0 +1,47 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"sector-analyzer/calendar"
+)
+
+func TestSessionDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		open   string // session open, RFC 3339
+		zone   string
+		offset int
+		want   string
+	}{
+		{"New York", "2024-06-04T09:30:00-04:00", "America/New_York", -4 * 3600, "2024-06-04"},
+		{"Sydney in summer", "2024-01-15T10:00:00+11:00", "Australia/Sydney", 11 * 3600, "2024-01-15"},
+		{"Sydney in winter", "2024-06-04T10:00:00+10:00", "Australia/Sydney", 10 * 3600, "2024-06-04"},
+		{"Auckland", "2024-02-01T10:00:00+13:00", "Pacific/Auckland", 13 * 3600, "2024-02-01"},
+		{"Tokyo", "2024-06-04T09:00:00+09:00", "Asia/Tokyo", 9 * 3600, "2024-06-04"},
+		{"offset only", "2024-01-15T10:00:00+11:00", "", 11 * 3600, "2024-01-15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, err := time.Parse(time.RFC3339, tt.open)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := sessionDate(open.Unix(), exchangeLocation(tt.zone, tt.offset))
+			if d := truncateDay(got).Format("2006-01-02"); d != tt.want {
+				t.Errorf("UTC date = %s, want %s", d, tt.want)
+			}
+			if d := calendar.NYSE.Date(got).Format("2006-01-02"); d != tt.want {
+				t.Errorf("New York date = %s, want %s", d, tt.want)
+			}
+			if !sessionDated(PriceSeries{{Date: got}}) {
+				t.Errorf("%s is not session dated", got)
+			}
+		})
+	}
+
+	if sessionDated(PriceSeries{{Date: time.Date(2024, 6, 4, 13, 30, 0, 0, time.UTC)}}) {
+		t.Error("a bar dated at the open is session dated")
+	}
+}
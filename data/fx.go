@@ -0,0 +1,168 @@
+// Currency conversion for international price series.
+
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"sector-analyzer/config"
+)
+
+// SourceFX is the progress/refresh source name for exchange rate conversion.
+const SourceFX = "fx"
+
+// FetchUSDRates retrieves the USD value of one unit of currency from FRED,
+// inverting series quoted as foreign units per USD.
+func FetchUSDRates(currency string, startDate time.Time) (TimeSeries, error) {
+	if currency == config.BaseCurrency {
+		return TimeSeries{}, nil
+	}
+
+	fx, ok := config.FXSeries[currency]
+	if !ok {
+		return TimeSeries{}, fmt.Errorf("no FX series configured for %s", currency)
+	}
+
+	ts, err := FetchFREDSeries(fx.SeriesID, startDate)
+	if err != nil {
+		return TimeSeries{}, fmt.Errorf("failed to fetch %s (%s): %w", fx.SeriesID, currency, err)
+	}
+	if len(ts.Values) == 0 {
+		return TimeSeries{}, fmt.Errorf("no observations for %s (%s)", fx.SeriesID, currency)
+	}
+	if fx.USDPerUnit {
+		return ts, nil
+	}
+
+	inverted := TimeSeries{
+		Dates:  make([]time.Time, 0, len(ts.Dates)),
+		Values: make([]float64, 0, len(ts.Values)),
+	}
+	for i, v := range ts.Values {
+		if v > 0 {
+			inverted.Dates = append(inverted.Dates, ts.Dates[i])
+			inverted.Values = append(inverted.Values, 1/v)
+		}
+	}
+	return inverted, nil
+}
+
+// ConvertPriceSeries converts prices using a per-date conversion factor,
+// applying the most recent rate on or before each bar's date. Bars earlier
+// than the first available rate are dropped. The input is not modified.
+func ConvertPriceSeries(series PriceSeries, rates TimeSeries) PriceSeries {
+	converted := make(PriceSeries, 0, len(series))
+
+	j := -1
+	for _, bar := range series {
+		day := truncateDay(bar.Date)
+		for j+1 < len(rates.Dates) && !truncateDay(rates.Dates[j+1]).After(day) {
+			j++
+		}
+		if j < 0 {
+			continue
+		}
+
+		rate := rates.Values[j]
+		bar.Open *= rate
+		bar.High *= rate
+		bar.Low *= rate
+		bar.Close *= rate
+		bar.AdjClose *= rate
+		bar.Dividend *= rate
+		converted = append(converted, bar)
+	}
+
+	return converted
+}
+
+// convertUniversePrices returns a copy of prices expressed in u.ConvertTo.
+// Series already in the target currency are shared with the input.
+func convertUniversePrices(u config.SectorUniverse, prices SectorPrices, progress ProgressFunc) (SectorPrices, error) {
+	// Currency of each price series, keyed like SectorPrices
+	currencies := map[string]string{"_benchmark": u.Currency}
+	for _, def := range u.Sectors {
+		currencies[def.Name] = u.SectorCurrency(def)
+	}
+
+	// Cover the full history, with a margin for FX holidays
+	start := time.Now()
+	for _, series := range prices {
+		if len(series) > 0 && series[0].Date.Before(start) {
+			start = series[0].Date
+		}
+	}
+	start = start.AddDate(0, 0, -10)
+
+	target, err := FetchUSDRates(u.ConvertTo, start)
+	if err != nil {
+		return prices, err
+	}
+
+	// Conversion factor per source currency: USD per unit / USD per target unit
+	factors := make(map[string]TimeSeries)
+	converted := make(SectorPrices)
+	var failed []string
+
+	for key, series := range prices {
+		currency := currencies[key]
+		if currency == "" || currency == u.ConvertTo {
+			converted[key] = series
+			continue
+		}
+
+		factor, ok := factors[currency]
+		if !ok {
+			usd, err := FetchUSDRates(currency, start)
+			if err != nil {
+				report(progress, SourceFX, currency, StageFailed, fmt.Sprintf("Error fetching %s rates: %v", currency, err))
+				failed = append(failed, currency)
+				factors[currency] = TimeSeries{}
+				continue
+			}
+			factor = crossRates(usd, target, u.ConvertTo)
+			factors[currency] = factor
+			report(progress, SourceFX, currency, StageProgress,
+				fmt.Sprintf("Fetched %d %s/%s rates", len(factor.Values), currency, u.ConvertTo))
+		}
+		if len(factor.Values) == 0 {
+			continue
+		}
+
+		converted[key] = ConvertPriceSeries(series, factor)
+	}
+
+	if len(failed) > 0 {
+		return converted, fmt.Errorf("no exchange rates for %v; those series were dropped", failed)
+	}
+	return converted, nil
+}
+
+// crossRates divides USD-per-unit rates by the target currency's USD rate on
+// matching dates, giving target units per source unit.
+func crossRates(usd, target TimeSeries, targetCurrency string) TimeSeries {
+	if targetCurrency == config.BaseCurrency {
+		return usd
+	}
+
+	byDate := make(map[time.Time]float64, len(target.Dates))
+	for i, d := range target.Dates {
+		byDate[truncateDay(d)] = target.Values[i]
+	}
+
+	var cross TimeSeries
+	for i, d := range usd.Dates {
+		if t, ok := byDate[truncateDay(d)]; ok && t > 0 {
+			cross.Dates = append(cross.Dates, d)
+			cross.Values = append(cross.Values, usd.Values[i]/t)
+		}
+	}
+	return cross
+}
+
+// truncateDay returns the UTC calendar day of t.
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,377 @@
+// ETF holdings and look-through sector metrics.
+
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sector-analyzer/config"
+)
+
+// holdingsColumns are the fields read from an ETF holdings export. Issuer
+// exports (SPDR, iShares, Vanguard) differ in layout and carry a preamble
+// above the header, so the header row is found by its cells.
+var holdingsColumns = []sheetColumn{
+	{"ticker", true, func(h string) bool { return h == "ticker" || h == "symbol" || h == "ticker symbol" }},
+	{"weight", true, func(h string) bool { return strings.HasPrefix(h, "weight") || strings.Contains(h, "% of") }},
+	{"name", false, func(h string) bool { return h == "name" || h == "security name" || h == "holding" || h == "company" }},
+}
+
+// fundamentalsColumns are the fields read from fundamentals.csv. Growth and
+// R&D intensity are fractions, or percentages with a % sign.
+var fundamentalsColumns = []sheetColumn{
+	{"ticker", true, func(h string) bool { return h == "ticker" || h == "symbol" }},
+	{"forward_pe", false, func(h string) bool {
+		return containsAny(h, "forward_pe", "forward pe", "p/e") || h == "pe"
+	}},
+	{"earnings_growth", false, func(h string) bool { return strings.Contains(h, "growth") }},
+	{"rd_intensity", false, func(h string) bool { return containsAny(h, "r&d", "rd_intensity") }},
+}
+
+// fetchLookThrough loads the holdings of each sector ETF from
+// config.HoldingsDir and aggregates their fundamentals into sector metrics.
+// Sectors without a holdings export are omitted.
+func fetchLookThrough(u config.SectorUniverse, progress ProgressFunc) (map[string][]Holding, map[string]LookThrough, error) {
+	holdings, err := loadHoldings(u, config.HoldingsDir, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fundamentals := make(map[string]ConstituentFundamentals)
+	path := filepath.Join(config.HoldingsDir, "fundamentals.csv")
+	if _, statErr := os.Stat(path); statErr == nil {
+		fundamentals, err = readFundamentalsFile(path)
+		if err != nil {
+			report(progress, SourceHoldings, "", StageWarning,
+				fmt.Sprintf("Warning: Could not read %s: %v", path, err))
+			fundamentals = make(map[string]ConstituentFundamentals)
+		}
+	}
+	if config.FetchConstituentFundamentals {
+		fetchMissingFundamentals(holdings, fundamentals, progress)
+	}
+
+	lookThrough := make(map[string]LookThrough, len(holdings))
+	for sector, h := range holdings {
+		lookThrough[sector] = aggregateLookThrough(h, fundamentals)
+	}
+	return holdings, lookThrough, nil
+}
+
+// loadHoldings reads <ETF>.csv for each sector of u from dir, matching file
+// names case-insensitively.
+func loadHoldings(u config.SectorUniverse, dir string, progress ProgressFunc) (map[string][]Holding, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holdings directory: %w", err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			files[strings.ToLower(e.Name())] = filepath.Join(dir, e.Name())
+		}
+	}
+
+	holdings := make(map[string][]Holding)
+	for _, def := range u.Sectors {
+		path, ok := files[strings.ToLower(def.ETF)+".csv"]
+		if !ok {
+			report(progress, SourceHoldings, def.ETF, StageWarning,
+				fmt.Sprintf("Warning: No holdings file for %s (%s)", def.Name, def.ETF))
+			continue
+		}
+		h, err := readHoldingsFile(path)
+		if err != nil {
+			report(progress, SourceHoldings, def.ETF, StageFailed,
+				fmt.Sprintf("Error reading holdings for %s (%s): %v", def.Name, def.ETF, err))
+			continue
+		}
+		holdings[def.Name] = h
+		report(progress, SourceHoldings, def.ETF, StageProgress,
+			fmt.Sprintf("Loaded %d holdings for %s (%s)", len(h), def.Name, def.ETF))
+	}
+
+	if len(holdings) == 0 {
+		return nil, fmt.Errorf("no holdings files found in %s", dir)
+	}
+	return holdings, nil
+}
+
+// readCSVTable reads a CSV file, tolerating ragged rows and stray quotes,
+// and locates its header row.
+func readCSVTable(path string, columns []sheetColumn) (sheetTable, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return sheetTable{}, err
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return sheetTable{}, err
+	}
+	return findTable([][][]string{rows}, columns)
+}
+
+// readHoldingsFile parses an ETF holdings export. Rows without a ticker,
+// such as cash lines and footnotes, are skipped. Weights quoted in percent
+// are converted to fractions.
+func readHoldingsFile(path string) ([]Holding, error) {
+	table, err := readCSVTable(path, holdingsColumns)
+	if err != nil {
+		return nil, fmt.Errorf("no ticker and weight header: %w", err)
+	}
+
+	var holdings []Holding
+	total := 0.0
+	for _, row := range table.rows {
+		ticker := normalizeTicker(table.cell(row, "ticker"))
+		weight, ok := parseSheetNumber(table.cell(row, "weight"))
+		if ticker == "" || !ok || weight <= 0 {
+			continue
+		}
+		holdings = append(holdings, Holding{Ticker: ticker, Name: table.cell(row, "name"), Weight: weight})
+		total += weight
+	}
+	if len(holdings) == 0 {
+		return nil, fmt.Errorf("no holdings with a weight")
+	}
+
+	// Weights summing to about 100 are percentages without a % sign
+	if total > 1.5 {
+		for i := range holdings {
+			holdings[i].Weight /= 100
+		}
+	}
+
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].Weight > holdings[j].Weight })
+	return holdings, nil
+}
+
+// normalizeTicker converts an issuer's ticker to Yahoo's form (BRK.B and
+// BRK/B become BRK-B). Placeholders such as "-" and cash lines give "".
+func normalizeTicker(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" || s == "-" || strings.Contains(s, " ") || strings.HasPrefix(s, "CASH") {
+		return ""
+	}
+	return strings.NewReplacer(".", "-", "/", "-").Replace(s)
+}
+
+// readFundamentalsFile parses fundamentals.csv, keyed by normalized ticker.
+func readFundamentalsFile(path string) (map[string]ConstituentFundamentals, error) {
+	table, err := readCSVTable(path, fundamentalsColumns)
+	if err != nil {
+		return nil, fmt.Errorf("no ticker header: %w", err)
+	}
+
+	fundamentals := make(map[string]ConstituentFundamentals)
+	for _, row := range table.rows {
+		ticker := normalizeTicker(table.cell(row, "ticker"))
+		if ticker == "" {
+			continue
+		}
+		var f ConstituentFundamentals
+		if v, ok := parseSheetNumber(table.cell(row, "forward_pe")); ok && v > 0 {
+			f.ForwardPE = &v
+		}
+		if v, ok := parseSheetNumber(table.cell(row, "earnings_growth")); ok {
+			f.EarningsGrowth = &v
+		}
+		if v, ok := parseSheetNumber(table.cell(row, "rd_intensity")); ok && v >= 0 {
+			f.RDIntensity = &v
+		}
+		fundamentals[ticker] = f
+	}
+	return fundamentals, nil
+}
+
+// fetchMissingFundamentals requests forward P/E and earnings growth from
+// Yahoo for holdings that fundamentals lacks either, filling them in place.
+// Yahoo's quote data has no R&D figure, so RDIntensity comes only from the
+// file.
+func fetchMissingFundamentals(holdings map[string][]Holding, fundamentals map[string]ConstituentFundamentals, progress ProgressFunc) {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, h := range holdings {
+		for _, holding := range h {
+			f := fundamentals[holding.Ticker]
+			if seen[holding.Ticker] || (f.ForwardPE != nil && f.EarningsGrowth != nil) {
+				continue
+			}
+			seen[holding.Ticker] = true
+			missing = append(missing, holding.Ticker)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	auth, err := getYahooCrumb()
+	if err != nil {
+		report(progress, SourceHoldings, "", StageWarning,
+			fmt.Sprintf("Warning: Could not authenticate with Yahoo Finance: %v", err))
+		return
+	}
+
+	report(progress, SourceHoldings, "", StageProgress,
+		fmt.Sprintf("Fetching fundamentals for %d constituents", len(missing)))
+	for _, ticker := range missing {
+		fetched, err := fetchConstituentFundamentals(ticker, auth)
+		if err != nil {
+			report(progress, SourceHoldings, ticker, StageWarning,
+				fmt.Sprintf("Warning: Could not fetch fundamentals for %s: %v", ticker, err))
+			continue
+		}
+		f := fundamentals[ticker]
+		if f.ForwardPE == nil {
+			f.ForwardPE = fetched.ForwardPE
+		}
+		if f.EarningsGrowth == nil {
+			f.EarningsGrowth = fetched.EarningsGrowth
+		}
+		fundamentals[ticker] = f
+	}
+}
+
+// fetchConstituentFundamentals retrieves a stock's forward P/E and earnings
+// growth from Yahoo, cached per ticker.
+func fetchConstituentFundamentals(ticker string, auth *yahooCrumb) (ConstituentFundamentals, error) {
+	cacheKey := GenerateKey("yfinance", map[string]interface{}{"type": "constituent_fundamentals", "ticker": ticker})
+	if cached, ok := GlobalCache.Get(cacheKey); ok {
+		return cached.(ConstituentFundamentals), nil
+	}
+
+	quoteSummary, err := requestQuoteSummary(ticker, "summaryDetail,defaultKeyStatistics,financialData", auth)
+	if err != nil {
+		return ConstituentFundamentals{}, err
+	}
+	var f ConstituentFundamentals
+	if len(quoteSummary.QuoteSummary.Result) > 0 {
+		result := quoteSummary.QuoteSummary.Result[0]
+		if result.SummaryDetail.ForwardPE.Raw > 0 {
+			pe := result.SummaryDetail.ForwardPE.Raw
+			f.ForwardPE = &pe
+		} else if result.DefaultKeyStatistics.ForwardPE.Raw > 0 {
+			pe := result.DefaultKeyStatistics.ForwardPE.Raw
+			f.ForwardPE = &pe
+		}
+		if result.FinancialData.EarningsGrowth.Fmt != "" {
+			growth := result.FinancialData.EarningsGrowth.Raw
+			f.EarningsGrowth = &growth
+		}
+	}
+
+	GlobalCache.Set(cacheKey, f)
+	// Small delay to avoid rate limiting
+	time.Sleep(200 * time.Millisecond)
+	return f, nil
+}
+
+// aggregateLookThrough combines a fund's holdings with their fundamentals.
+// P/E is the harmonic weighted mean, which is the fund's price over its share
+// of the holdings' earnings; growth and R&D intensity are weighted means.
+// Each metric uses only the holdings that report it, reweighted to sum to one.
+func aggregateLookThrough(holdings []Holding, fundamentals map[string]ConstituentFundamentals) LookThrough {
+	lt := LookThrough{Holdings: len(holdings)}
+
+	total := 0.0
+	for _, h := range holdings {
+		total += h.Weight
+	}
+	if total <= 0 {
+		return lt
+	}
+
+	var herfindahl, covered float64
+	var earningsYield, peWeight, growth, growthWeight, rd, rdWeight float64
+	for i, h := range holdings {
+		w := h.Weight / total
+		herfindahl += w * w
+		if i < 10 {
+			lt.TopTenWeight += h.Weight
+		}
+
+		f, ok := fundamentals[h.Ticker]
+		if !ok || (f.ForwardPE == nil && f.EarningsGrowth == nil && f.RDIntensity == nil) {
+			continue
+		}
+		covered += h.Weight
+		if f.ForwardPE != nil {
+			earningsYield += w / *f.ForwardPE
+			peWeight += w
+		}
+		if f.EarningsGrowth != nil {
+			growth += w * *f.EarningsGrowth
+			growthWeight += w
+		}
+		if f.RDIntensity != nil {
+			rd += w * *f.RDIntensity
+			rdWeight += w
+		}
+	}
+
+	lt.EffectiveHoldings = 1 / herfindahl
+	lt.Coverage = covered / total
+	if peWeight > 0 && earningsYield > 0 {
+		pe := peWeight / earningsYield
+		lt.ForwardPE = &pe
+	}
+	if growthWeight > 0 {
+		g := growth / growthWeight
+		lt.EarningsGrowth = &g
+	}
+	if rdWeight > 0 {
+		r := rd / rdWeight
+		lt.RDIntensity = &r
+	}
+	return lt
+}
+
+// fetchConstituentPrices fetches two years of prices for the largest
+// config.BreadthConstituents holdings of each sector, keyed by ticker.
+// Tickers that cannot be fetched are reported and skipped.
+func fetchConstituentPrices(holdings map[string][]Holding, progress ProgressFunc) (map[string]PriceSeries, error) {
+	var tickers []string
+	seen := make(map[string]bool)
+	for _, h := range holdings {
+		for i, holding := range h {
+			if i >= config.BreadthConstituents {
+				break
+			}
+			if !seen[holding.Ticker] {
+				seen[holding.Ticker] = true
+				tickers = append(tickers, holding.Ticker)
+			}
+		}
+	}
+	sort.Strings(tickers)
+
+	prices := make(map[string]PriceSeries, len(tickers))
+	var lastErr error
+	for _, ticker := range tickers {
+		series, _, err := updatePriceHistory(ticker, "2y", progress)
+		if err != nil {
+			report(progress, SourceConstituents, ticker, StageWarning,
+				fmt.Sprintf("Warning: Could not fetch prices for %s: %v", ticker, err))
+			lastErr = err
+			continue
+		}
+		prices[ticker] = series
+	}
+	report(progress, SourceConstituents, "", StageProgress,
+		fmt.Sprintf("Fetched prices for %d of %d constituents", len(prices), len(tickers)))
+
+	if len(prices) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return prices, nil
+}
@@ -0,0 +1,64 @@
+// Progress reporting for data refreshes.
+
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+// Source names used in progress events and refresh status.
+const (
+	SourceYahooPrices  = "yahoo_prices"
+	SourceYahooInfo    = "yahoo_info"
+	SourceFRED         = "fred"
+	SourceBLS          = "bls"
+	SourceDamodaran    = "damodaran"
+	SourceHoldings     = "holdings"
+	SourceConstituents = "constituents"
+	SourceValidation   = "validation"
+)
+
+// Progress stages reported for a source or ticker.
+const (
+	StageStarted   = "started"
+	StageProgress  = "progress"
+	StageWarning   = "warning"
+	StageFailed    = "failed"
+	StageCompleted = "completed"
+)
+
+// ProgressEvent describes a single step of a data refresh.
+type ProgressEvent struct {
+	Source  string    `json:"source"`
+	Ticker  string    `json:"ticker,omitempty"`
+	Stage   string    `json:"stage"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// ProgressFunc receives progress events while data is being fetched.
+type ProgressFunc func(ProgressEvent)
+
+// PrintProgress writes source-level steps and failures to stdout,
+// matching the console output of a plain FetchAllData call.
+func PrintProgress(ev ProgressEvent) {
+	if ev.Ticker != "" && ev.Stage == StageProgress {
+		return
+	}
+	fmt.Println(ev.Message)
+}
+
+// report sends an event to the progress callback, defaulting to stdout.
+func report(progress ProgressFunc, source, ticker, stage, message string) {
+	if progress == nil {
+		progress = PrintProgress
+	}
+	progress(ProgressEvent{
+		Source:  source,
+		Ticker:  ticker,
+		Stage:   stage,
+		Message: message,
+		Time:    time.Now(),
+	})
+}
@@ -0,0 +1,173 @@
+// The persistence interface for fetched time series.
+
+package data
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"sector-analyzer/config"
+)
+
+// PriceRecord is the stored daily history of one ticker. From is the start
+// of the range that has been requested, which may precede the first bar.
+type PriceRecord struct {
+	Ticker    string      `json:"ticker"`
+	From      time.Time   `json:"from"`
+	Bars      PriceSeries `json:"bars"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// SeriesRecord is the stored history of one FRED or BLS series. Source is
+// SourceFRED or SourceBLS.
+type SeriesRecord struct {
+	ID        string     `json:"id"`
+	Source    string     `json:"source"`
+	From      time.Time  `json:"from"`
+	Series    TimeSeries `json:"series"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IndustryRDRecord is a parsed Damodaran R&D dataset, by industry.
+type IndustryRDRecord struct {
+	Industries map[string]IndustryRD `json:"industries"`
+	FetchedAt  time.Time             `json:"fetched_at"`
+}
+
+// SeriesStore persists fetched histories so refreshes only request new data
+// and the last good values survive restarts. Load methods report false when
+// nothing has been stored.
+type SeriesStore interface {
+	LoadPrices(ticker string) (PriceRecord, bool, error)
+	SavePrices(rec PriceRecord) error
+	LoadSeries(id string) (SeriesRecord, bool, error)
+	SaveSeries(rec SeriesRecord) error
+	LoadIndustryRD() (IndustryRDRecord, bool, error)
+	SaveIndustryRD(rec IndustryRDRecord) error
+	LoadRDArchive(year int) (IndustryRDRecord, bool, error)
+	SaveRDArchive(year int, rec IndustryRDRecord) error
+	LoadVintages(id string) ([]Vintage, bool, error)
+	SaveVintages(id string, vintages []Vintage) error
+}
+
+// noStore stores nothing, so every fetch requests the full history. It is
+// the default until a database is installed as GlobalStore.
+type noStore struct{}
+
+func (noStore) LoadPrices(string) (PriceRecord, bool, error)  { return PriceRecord{}, false, nil }
+func (noStore) SavePrices(PriceRecord) error                  { return nil }
+func (noStore) LoadSeries(string) (SeriesRecord, bool, error) { return SeriesRecord{}, false, nil }
+func (noStore) SaveSeries(SeriesRecord) error                 { return nil }
+func (noStore) LoadIndustryRD() (IndustryRDRecord, bool, error) {
+	return IndustryRDRecord{}, false, nil
+}
+func (noStore) SaveIndustryRD(IndustryRDRecord) error { return nil }
+func (noStore) LoadRDArchive(int) (IndustryRDRecord, bool, error) {
+	return IndustryRDRecord{}, false, nil
+}
+func (noStore) SaveRDArchive(int, IndustryRDRecord) error    { return nil }
+func (noStore) LoadVintages(string) ([]Vintage, bool, error) { return nil, false, nil }
+func (noStore) SaveVintages(string, []Vintage) error         { return nil }
+
+// GlobalStore is the series store used by the fetchers.
+var GlobalStore SeriesStore = noStore{}
+
+// revisionStart returns the date from which an update should re-request
+// data: the config.RevisionWindow-th most recent stored date, so late
+// revisions to the last few bars are picked up.
+func revisionStart(dates []time.Time) time.Time {
+	i := len(dates) - config.RevisionWindow
+	if i < 0 {
+		i = 0
+	}
+	return dates[i]
+}
+
+// mergePriceSeries combines stored bars with freshly fetched ones. Fresh bars
+// replace stored bars for the same day; the result is sorted by date.
+func mergePriceSeries(stored, fresh PriceSeries) PriceSeries {
+	if len(fresh) == 0 {
+		return stored
+	}
+
+	cutoff := truncateDay(fresh[0].Date)
+	merged := make(PriceSeries, 0, len(stored)+len(fresh))
+	for _, bar := range stored {
+		if truncateDay(bar.Date).Before(cutoff) {
+			merged = append(merged, bar)
+		}
+	}
+	merged = append(merged, fresh...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Date.Before(merged[j].Date) })
+	return merged
+}
+
+// mergeTimeSeries combines stored observations with fresh ones, which
+// replace stored values from the first fresh date onwards.
+func mergeTimeSeries(stored, fresh TimeSeries) TimeSeries {
+	if len(fresh.Dates) == 0 {
+		return stored
+	}
+
+	cutoff := fresh.Dates[0]
+	for _, d := range fresh.Dates {
+		if d.Before(cutoff) {
+			cutoff = d
+		}
+	}
+
+	var merged TimeSeries
+	for i, d := range stored.Dates {
+		if d.Before(cutoff) {
+			merged.appendPoint(d, stored.Values[i], stored.IsPreliminary(i))
+		}
+	}
+	for i, d := range fresh.Dates {
+		merged.appendPoint(d, fresh.Values[i], fresh.IsPreliminary(i))
+	}
+	sortTimeSeries(&merged)
+	return merged
+}
+
+// adjustmentsChanged reports whether fresh bars invalidate the stored
+// history: a dividend or split not seen before re-bases every earlier
+// adjusted close, as does a changed adjusted close on the first overlapping day.
+func adjustmentsChanged(stored, fresh PriceSeries) bool {
+	if len(fresh) == 0 {
+		return false
+	}
+
+	byDay := make(map[time.Time]PriceBar, len(stored))
+	for _, bar := range stored {
+		byDay[truncateDay(bar.Date)] = bar
+	}
+
+	for _, bar := range fresh {
+		old, ok := byDay[truncateDay(bar.Date)]
+		if (bar.Dividend > 0 || bar.SplitRatio > 0) && (!ok || old.Dividend != bar.Dividend || old.SplitRatio != bar.SplitRatio) {
+			return true
+		}
+	}
+
+	if old, ok := byDay[truncateDay(fresh[0].Date)]; ok && old.AdjClose > 0 && fresh[0].AdjClose > 0 {
+		return math.Abs(fresh[0].AdjClose/old.AdjClose-1) > 0.001
+	}
+	return false
+}
+
+// priceWindow returns the bars on or after start.
+func priceWindow(series PriceSeries, start time.Time) PriceSeries {
+	i := sort.Search(len(series), func(i int) bool { return !series[i].Date.Before(start) })
+	return series[i:]
+}
+
+// seriesWindow returns the observations on or after start.
+func seriesWindow(ts TimeSeries, start time.Time) TimeSeries {
+	i := sort.Search(len(ts.Dates), func(i int) bool { return !ts.Dates[i].Before(start) })
+	window := TimeSeries{Dates: ts.Dates[i:], Values: ts.Values[i:]}
+	if ts.Preliminary != nil {
+		window.Preliminary = ts.Preliminary[i:]
+	}
+	return window
+}
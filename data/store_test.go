@@ -0,0 +1,78 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// monthly builds a series with an observation on the first of each given
+// month of 2024, flagging the months in preliminary.
+func monthly(values map[int]float64, preliminary ...int) TimeSeries {
+	var ts TimeSeries
+	for month := 1; month <= 12; month++ {
+		v, ok := values[month]
+		if !ok {
+			continue
+		}
+		flagged := false
+		for _, p := range preliminary {
+			flagged = flagged || p == month
+		}
+		ts.appendPoint(time.Date(2024, time.Month(month), 1, 0, 0, 0, 0, time.UTC), v, flagged)
+	}
+	return ts
+}
+
+func TestMergeTimeSeries(t *testing.T) {
+	tests := []struct {
+		name          string
+		stored, fresh TimeSeries
+		want          TimeSeries
+	}{
+		{
+			"fresh replaces stored from its first date",
+			monthly(map[int]float64{1: 1, 2: 2, 3: 3}),
+			monthly(map[int]float64{2: 20, 3: 30, 4: 40}),
+			monthly(map[int]float64{1: 1, 2: 20, 3: 30, 4: 40}),
+		},
+		{
+			"nothing fresh",
+			monthly(map[int]float64{1: 1, 2: 2}),
+			TimeSeries{},
+			monthly(map[int]float64{1: 1, 2: 2}),
+		},
+		{
+			"preliminary flags stay with their values",
+			monthly(map[int]float64{1: 1, 2: 2, 3: 3}, 3),
+			monthly(map[int]float64{3: 30, 4: 40}, 4),
+			monthly(map[int]float64{1: 1, 2: 2, 3: 30, 4: 40}, 4),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeTimeSeries(tt.stored, tt.fresh); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeTimeSeries = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortTimeSeries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	ts := TimeSeries{
+		Dates:       []time.Time{day(3), day(1), day(4), day(2)},
+		Values:      []float64{3, 1, 4, 2},
+		Preliminary: []bool{false, false, true, false},
+	}
+	sortTimeSeries(&ts)
+
+	want := TimeSeries{
+		Dates:       []time.Time{day(1), day(2), day(3), day(4)},
+		Values:      []float64{1, 2, 3, 4},
+		Preliminary: []bool{false, false, false, true},
+	}
+	if !reflect.DeepEqual(ts, want) {
+		t.Errorf("sortTimeSeries = %+v, want %+v", ts, want)
+	}
+}
@@ -4,13 +4,31 @@ package data
 import "time"
 
 // PriceBar represents a single price record.
+//
+// Date is noon UTC on the session's date at its exchange, so it falls on
+// that date in UTC and in New York alike. Close is split-adjusted but
+// excludes dividends; AdjClose is adjusted for both, so returns computed
+// from it are total returns. Dividend and SplitRatio are set on ex-dates
+// only.
 type PriceBar struct {
-	Date   time.Time `json:"date"`
-	Open   float64   `json:"open"`
-	High   float64   `json:"high"`
-	Low    float64   `json:"low"`
-	Close  float64   `json:"close"`
-	Volume int64     `json:"volume"`
+	Date       time.Time `json:"date"`
+	Open       float64   `json:"open"`
+	High       float64   `json:"high"`
+	Low        float64   `json:"low"`
+	Close      float64   `json:"close"`
+	AdjClose   float64   `json:"adj_close"`
+	Volume     int64     `json:"volume"`
+	Dividend   float64   `json:"dividend,omitempty"`
+	SplitRatio float64   `json:"split_ratio,omitempty"`
+}
+
+// TotalReturnClose returns the dividend-adjusted close, or Close when no
+// adjusted value is available.
+func (b PriceBar) TotalReturnClose() float64 {
+	if b.AdjClose > 0 {
+		return b.AdjClose
+	}
+	return b.Close
 }
 
 // PriceSeries is a slice of price bars ordered by date.
@@ -26,12 +44,43 @@ type SectorInfo struct {
 	DividendYield *float64 `json:"dividend_yield"`
 	AvgVolume     *int64   `json:"avg_volume"`
 	MarketCap     *float64 `json:"market_cap"`
+	// Fundamentals of the ETF's equity holdings. ReturnOnEquity and
+	// NetMargin are derived from the holdings' valuation ratios.
+	PriceToBook     *float64 `json:"price_to_book"`
+	PriceToSales    *float64 `json:"price_to_sales"`
+	PEGRatio        *float64 `json:"peg_ratio"`
+	EarningsGrowth  *float64 `json:"earnings_growth"`
+	ReturnOnEquity  *float64 `json:"return_on_equity"`
+	NetMargin       *float64 `json:"net_margin"`
+	DividendYield5y *float64 `json:"dividend_yield_5y"`
+	NetAssets       *float64 `json:"net_assets"`
 }
 
 // TimeSeries represents a time-indexed series of float values.
 type TimeSeries struct {
 	Dates  []time.Time `json:"dates"`
 	Values []float64   `json:"values"`
+	// Preliminary flags values still subject to revision (BLS only). When
+	// present it is aligned with Dates.
+	Preliminary []bool `json:"preliminary,omitempty"`
+}
+
+// IsPreliminary reports whether observation i is a preliminary estimate.
+func (ts TimeSeries) IsPreliminary(i int) bool {
+	return i < len(ts.Preliminary) && ts.Preliminary[i]
+}
+
+// appendPoint adds an observation, keeping Preliminary aligned once any
+// observation has been flagged.
+func (ts *TimeSeries) appendPoint(date time.Time, value float64, preliminary bool) {
+	if preliminary && ts.Preliminary == nil {
+		ts.Preliminary = make([]bool, len(ts.Dates), cap(ts.Dates))
+	}
+	ts.Dates = append(ts.Dates, date)
+	ts.Values = append(ts.Values, value)
+	if ts.Preliminary != nil {
+		ts.Preliminary = append(ts.Preliminary, preliminary)
+	}
 }
 
 // MacroData contains FRED time series data.
@@ -43,14 +92,76 @@ type EmploymentData map[string]TimeSeries
 // RDData maps sectors to R&D intensity values.
 type RDData map[string]float64
 
-// AllData aggregates all fetched data sources.
+// IndustryRD holds one Damodaran industry's R&D figures. Revenue and RD are
+// aggregate dollar amounts and are 0 when the dataset does not provide them.
+type IndustryRD struct {
+	Firms     int     `json:"firms,omitempty"`
+	Revenue   float64 `json:"revenue,omitempty"`
+	RD        float64 `json:"rd,omitempty"`
+	Intensity float64 `json:"intensity"`
+}
+
+// IndustryCoverage compares a Damodaran dataset with a universe's sector
+// definitions: Unmapped industries are in the dataset but assigned to no
+// sector, Missing ones are assigned but absent from the dataset.
+type IndustryCoverage struct {
+	Unmapped []string `json:"unmapped"`
+	Missing  []string `json:"missing"`
+}
+
+// Holding is one constituent of a sector ETF. Weight is a fraction of the fund.
+type Holding struct {
+	Ticker string  `json:"ticker"`
+	Name   string  `json:"name,omitempty"`
+	Weight float64 `json:"weight"`
+}
+
+// ConstituentFundamentals holds the figures aggregated from a holding.
+// RDIntensity is R&D as a fraction of revenue.
+type ConstituentFundamentals struct {
+	ForwardPE      *float64 `json:"forward_pe,omitempty"`
+	EarningsGrowth *float64 `json:"earnings_growth,omitempty"`
+	RDIntensity    *float64 `json:"rd_intensity,omitempty"`
+}
+
+// LookThrough is a sector's metrics aggregated from its ETF holdings.
+// EffectiveHoldings is the inverse Herfindahl index of the weights, so 10
+// equal holdings give 10 and a fund dominated by one stock gives about 1.
+// Coverage is the fraction of the fund's weight with fundamentals.
+type LookThrough struct {
+	Holdings          int      `json:"holdings"`
+	EffectiveHoldings float64  `json:"effective_holdings"`
+	TopTenWeight      float64  `json:"top_ten_weight"`
+	Coverage          float64  `json:"coverage"`
+	ForwardPE         *float64 `json:"forward_pe"`
+	EarningsGrowth    *float64 `json:"earnings_growth"`
+	RDIntensity       *float64 `json:"rd_intensity"`
+}
+
+// RDHistory maps sectors to R&D intensity by Damodaran dataset year.
+type RDHistory map[string]TimeSeries
+
+// AllData aggregates all fetched data sources for one universe.
 type AllData struct {
+	Universe       string                 `json:"universe"`
+	Benchmark      string                 `json:"benchmark"`
+	Currency       string                 `json:"currency"`
+	Sectors        []string               `json:"sectors"`
 	SectorPrices   SectorPrices           `json:"sector_prices"`
 	SectorInfo     map[string]SectorInfo  `json:"sector_info"`
 	MacroData      MacroData              `json:"macro_data"`
 	EmploymentData EmploymentData         `json:"employment_data"`
 	RDData         RDData                 `json:"rd_data"`
+	RDHistory      RDHistory              `json:"rd_history,omitempty"`
+	Industries     *IndustryCoverage      `json:"industries,omitempty"`
+	Holdings       map[string][]Holding   `json:"holdings,omitempty"`
+	LookThrough    map[string]LookThrough `json:"look_through,omitempty"`
+	FailedSources  map[string]string      `json:"failed_sources,omitempty"`
+	Issues         DataIssues             `json:"issues,omitempty"`
 	FetchedAt      time.Time              `json:"fetched_at"`
+	AsOf           *time.Time             `json:"as_of,omitempty"`
+	// ConstituentPrices holds price series of the largest holdings, by ticker.
+	ConstituentPrices map[string]PriceSeries `json:"constituent_prices,omitempty"`
 }
 
 // YahooFinanceResponse structures for parsing Yahoo Finance API responses.
@@ -58,9 +169,11 @@ type YahooFinanceChart struct {
 	Chart struct {
 		Result []struct {
 			Meta struct {
-				Symbol             string  `json:"symbol"`
-				RegularMarketPrice float64 `json:"regularMarketPrice"`
-				PreviousClose      float64 `json:"previousClose"`
+				Symbol               string  `json:"symbol"`
+				RegularMarketPrice   float64 `json:"regularMarketPrice"`
+				PreviousClose        float64 `json:"previousClose"`
+				ExchangeTimezoneName string  `json:"exchangeTimezoneName"`
+				GMTOffset            int     `json:"gmtoffset"`
 			} `json:"meta"`
 			Timestamp  []int64 `json:"timestamp"`
 			Indicators struct {
@@ -71,24 +184,63 @@ type YahooFinanceChart struct {
 					Close  []float64 `json:"close"`
 					Volume []int64   `json:"volume"`
 				} `json:"quote"`
+				AdjClose []struct {
+					AdjClose []float64 `json:"adjclose"`
+				} `json:"adjclose"`
 			} `json:"indicators"`
+			Events struct {
+				Dividends map[string]YahooDividend `json:"dividends"`
+				Splits    map[string]YahooSplit    `json:"splits"`
+			} `json:"events"`
 		} `json:"result"`
 		Error interface{} `json:"error"`
 	} `json:"chart"`
 }
 
+// YahooDividend is a dividend event from the chart endpoint.
+type YahooDividend struct {
+	Amount float64 `json:"amount"`
+	Date   int64   `json:"date"`
+}
+
+// YahooSplit is a split event from the chart endpoint.
+type YahooSplit struct {
+	Date        int64   `json:"date"`
+	Numerator   float64 `json:"numerator"`
+	Denominator float64 `json:"denominator"`
+	SplitRatio  string  `json:"splitRatio"`
+}
+
 // YahooQuoteSummary for getting ETF info.
 type YahooQuoteSummary struct {
 	QuoteSummary struct {
 		Result []struct {
 			SummaryDetail struct {
-				ForwardPE     YahooValue `json:"forwardPE"`
-				TrailingPE    YahooValue `json:"trailingPE"`
-				DividendYield YahooValue `json:"dividendYield"`
+				ForwardPE                YahooValue `json:"forwardPE"`
+				TrailingPE               YahooValue `json:"trailingPE"`
+				DividendYield            YahooValue `json:"dividendYield"`
+				Yield                    YahooValue `json:"yield"`
+				FiveYearAvgDividendYield YahooValue `json:"fiveYearAvgDividendYield"`
+				AverageVolume            YahooValue `json:"averageVolume"`
+				MarketCap                YahooValue `json:"marketCap"`
+				TotalAssets              YahooValue `json:"totalAssets"`
 			} `json:"summaryDetail"`
 			DefaultKeyStatistics struct {
-				ForwardPE YahooValue `json:"forwardPE"`
+				ForwardPE   YahooValue `json:"forwardPE"`
+				PegRatio    YahooValue `json:"pegRatio"`
+				TotalAssets YahooValue `json:"totalAssets"`
 			} `json:"defaultKeyStatistics"`
+			TopHoldings struct {
+				EquityHoldings struct {
+					PriceToEarnings         YahooValue `json:"priceToEarnings"`
+					PriceToBook             YahooValue `json:"priceToBook"`
+					PriceToSales            YahooValue `json:"priceToSales"`
+					ThreeYearEarningsGrowth YahooValue `json:"threeYearEarningsGrowth"`
+				} `json:"equityHoldings"`
+			} `json:"topHoldings"`
+			FinancialData struct {
+				EarningsGrowth YahooValue `json:"earningsGrowth"`
+			} `json:"financialData"`
 		} `json:"result"`
 	} `json:"quoteSummary"`
 }
@@ -102,8 +254,10 @@ type YahooValue struct {
 // FREDResponse for parsing FRED API responses.
 type FREDResponse struct {
 	Observations []struct {
-		Date  string `json:"date"`
-		Value string `json:"value"`
+		Date          string `json:"date"`
+		Value         string `json:"value"`
+		RealtimeStart string `json:"realtime_start"`
+		RealtimeEnd   string `json:"realtime_end"`
 	} `json:"observations"`
 }
 
@@ -115,9 +269,13 @@ type BLSResponse struct {
 		Series []struct {
 			SeriesID string `json:"seriesID"`
 			Data     []struct {
-				Year   string `json:"year"`
-				Period string `json:"period"`
-				Value  string `json:"value"`
+				Year      string `json:"year"`
+				Period    string `json:"period"`
+				Value     string `json:"value"`
+				Footnotes []struct {
+					Code string `json:"code"`
+					Text string `json:"text"`
+				} `json:"footnotes"`
 			} `json:"data"`
 		} `json:"series"`
 	} `json:"Results"`
@@ -0,0 +1,419 @@
+// Validation and repair of fetched series.
+
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"sector-analyzer/calendar"
+	"sector-analyzer/config"
+)
+
+// Issue kinds reported by validation.
+const (
+	IssueInvalidValue = "invalid_value"
+	IssueOutOfOrder   = "out_of_order"
+	IssueDuplicate    = "duplicate"
+	IssueBadTick      = "implausible_move"
+	IssueStaleClose   = "stale_close"
+	IssueZeroVolume   = "zero_volume"
+	IssueMissingDays  = "missing_trading_days"
+	IssueClosedMarket = "closed_market"
+)
+
+// DataIssue describes one problem found in a series. Repaired issues were
+// fixed in the returned series; the rest are reported only.
+type DataIssue struct {
+	Kind     string    `json:"kind"`
+	Date     time.Time `json:"date"`
+	Message  string    `json:"message"`
+	Repaired bool      `json:"repaired"`
+}
+
+// DataIssues maps a ticker or series ID to its validation issues.
+type DataIssues map[string][]DataIssue
+
+// ValidatePriceSeries checks a price series against its exchange calendar
+// and returns a repaired copy. Non-positive closes or adjusted closes,
+// duplicate days, bars on exchange holidays and isolated bad ticks in either
+// close are removed and out-of-order bars are sorted. Large moves that
+// persist, stale closes, zero-volume stretches and missing trading days are
+// reported but kept; a session whose bar was removed is not missing.
+func ValidatePriceSeries(series PriceSeries, cal *calendar.Calendar) (PriceSeries, []DataIssue) {
+	var issues []DataIssue
+
+	clean := make(PriceSeries, 0, len(series))
+	for _, bar := range series {
+		if !validValue(bar.Close) || bar.Close <= 0 {
+			issues = append(issues, DataIssue{
+				Kind:     IssueInvalidValue,
+				Date:     bar.Date,
+				Message:  fmt.Sprintf("invalid close %v dropped", bar.Close),
+				Repaired: true,
+			})
+			continue
+		}
+		// An unset adjusted close is zero; signals then fall back to Close
+		if bar.AdjClose != 0 && (!validValue(bar.AdjClose) || bar.AdjClose < 0) {
+			issues = append(issues, DataIssue{
+				Kind:     IssueInvalidValue,
+				Date:     bar.Date,
+				Message:  fmt.Sprintf("invalid adjusted close %v dropped", bar.AdjClose),
+				Repaired: true,
+			})
+			continue
+		}
+		clean = append(clean, bar)
+	}
+
+	byDate := func(i, j int) bool { return clean[i].Date.Before(clean[j].Date) }
+	if !sort.SliceIsSorted(clean, byDate) {
+		sort.SliceStable(clean, byDate)
+		issues = append(issues, DataIssue{
+			Kind:     IssueOutOfOrder,
+			Date:     clean[0].Date,
+			Message:  "bars were out of order and have been sorted",
+			Repaired: true,
+		})
+	}
+
+	// Keep the last bar seen for each trading day
+	deduped := clean[:0]
+	for _, bar := range clean {
+		if n := len(deduped); n > 0 && truncateDay(deduped[n-1].Date).Equal(truncateDay(bar.Date)) {
+			issues = append(issues, DataIssue{
+				Kind:     IssueDuplicate,
+				Date:     bar.Date,
+				Message:  "duplicate bar for trading day dropped",
+				Repaired: true,
+			})
+			deduped[n-1] = bar
+			continue
+		}
+		deduped = append(deduped, bar)
+	}
+	clean = deduped
+
+	// A bar on a day the exchange was closed is a stale repeat, not a session
+	if !cal.Approximate {
+		open := clean[:0]
+		for _, bar := range clean {
+			if !cal.IsTradingDay(bar.Date) {
+				reason := "weekend"
+				if name, ok := cal.Holiday(bar.Date); ok {
+					reason = name
+				}
+				issues = append(issues, DataIssue{
+					Kind:     IssueClosedMarket,
+					Date:     bar.Date,
+					Message:  fmt.Sprintf("bar on a %s closed day (%s) dropped", cal.Name, reason),
+					Repaired: true,
+				})
+				continue
+			}
+			open = append(open, bar)
+		}
+		clean = open
+	}
+
+	clean, moveIssues := removeBadTicks(clean)
+	issues = append(issues, moveIssues...)
+	issues = append(issues, findRuns(clean)...)
+
+	// Sessions whose bar was dropped for its values did trade
+	var dropped []time.Time
+	for _, issue := range issues {
+		if issue.Repaired && (issue.Kind == IssueInvalidValue || issue.Kind == IssueBadTick) {
+			dropped = append(dropped, issue.Date)
+		}
+	}
+	issues = append(issues, findGaps(clean, cal, dropped)...)
+
+	return clean, issues
+}
+
+// removeBadTicks drops single bars whose close or adjusted close jumps more
+// than MaxDailyMove and reverts on the next bar. Moves that persist are
+// reported but kept.
+func removeBadTicks(series PriceSeries) (PriceSeries, []DataIssue) {
+	var issues []DataIssue
+	if len(series) < 2 {
+		return series, nil
+	}
+
+	kept := PriceSeries{series[0]}
+	for i := 1; i < len(series); i++ {
+		prev := kept[len(kept)-1]
+		move := barMove(prev, series[i])
+		if math.Abs(move) <= config.MaxDailyMove {
+			kept = append(kept, series[i])
+			continue
+		}
+
+		if i+1 < len(series) && math.Abs(barMove(prev, series[i+1])) <= config.MaxDailyMove/2 {
+			issues = append(issues, DataIssue{
+				Kind:     IssueBadTick,
+				Date:     series[i].Date,
+				Message:  fmt.Sprintf("%+.1f%% spike reverted next day; bar dropped", move*100),
+				Repaired: true,
+			})
+			continue
+		}
+
+		issues = append(issues, DataIssue{
+			Kind:    IssueBadTick,
+			Date:    series[i].Date,
+			Message: fmt.Sprintf("%+.1f%% daily move", move*100),
+		})
+		kept = append(kept, series[i])
+	}
+
+	return kept, issues
+}
+
+// barMove returns the larger move from one bar to a later one, of the close
+// and, when both bars have one, of the adjusted close.
+func barMove(from, to PriceBar) float64 {
+	move := to.Close/from.Close - 1
+	if from.AdjClose > 0 && to.AdjClose > 0 {
+		if adj := to.AdjClose/from.AdjClose - 1; math.Abs(adj) > math.Abs(move) {
+			move = adj
+		}
+	}
+	return move
+}
+
+// findRuns reports stretches of unchanged closes and of zero volume.
+func findRuns(series PriceSeries) []DataIssue {
+	var issues []DataIssue
+
+	flush := func(kind string, start, length, min int, what string) {
+		if length >= min {
+			issues = append(issues, DataIssue{
+				Kind:    kind,
+				Date:    series[start].Date,
+				Message: fmt.Sprintf("%s for %d consecutive bars", what, length),
+			})
+		}
+	}
+
+	staleStart, zeroStart := 0, -1
+	for i, bar := range series {
+		if i > 0 && bar.Close != series[i-1].Close {
+			flush(IssueStaleClose, staleStart, i-staleStart, config.StaleCloseRun, "unchanged close")
+			staleStart = i
+		}
+
+		if bar.Volume == 0 {
+			if zeroStart < 0 {
+				zeroStart = i
+			}
+		} else if zeroStart >= 0 {
+			flush(IssueZeroVolume, zeroStart, i-zeroStart, config.ZeroVolumeRun, "zero volume")
+			zeroStart = -1
+		}
+	}
+	if len(series) > 0 {
+		flush(IssueStaleClose, staleStart, len(series)-staleStart, config.StaleCloseRun, "unchanged close")
+	}
+	if zeroStart >= 0 {
+		flush(IssueZeroVolume, zeroStart, len(series)-zeroStart, config.ZeroVolumeRun, "zero volume")
+	}
+
+	return issues
+}
+
+// findGaps reports sessions missing between consecutive bars, not counting
+// the dropped sessions, whose bars existed but were removed.
+func findGaps(series PriceSeries, cal *calendar.Calendar, dropped []time.Time) []DataIssue {
+	minMissing := 1
+	if cal.Approximate {
+		minMissing = config.MaxMissingTradingDays
+	}
+
+	var issues []DataIssue
+	for i := 1; i < len(series); i++ {
+		missing := cal.TradingDaysBetween(series[i-1].Date, series[i].Date)
+		for _, d := range dropped {
+			day := truncateDay(d)
+			if day.After(truncateDay(series[i-1].Date)) && day.Before(truncateDay(series[i].Date)) && cal.IsTradingDay(d) {
+				missing--
+			}
+		}
+		if missing >= minMissing {
+			issues = append(issues, DataIssue{
+				Kind:    IssueMissingDays,
+				Date:    series[i-1].Date,
+				Message: fmt.Sprintf("%d trading days missing before %s", missing, series[i].Date.Format("2006-01-02")),
+			})
+		}
+	}
+	return issues
+}
+
+// ValidateTimeSeries checks a macro or employment series and returns a
+// repaired copy with NaN/Inf values and duplicate dates removed, sorted by date.
+func ValidateTimeSeries(ts TimeSeries) (TimeSeries, []DataIssue) {
+	var issues []DataIssue
+
+	n := len(ts.Dates)
+	if len(ts.Values) != n {
+		if len(ts.Values) < n {
+			n = len(ts.Values)
+		}
+		issues = append(issues, DataIssue{
+			Kind:     IssueInvalidValue,
+			Message:  fmt.Sprintf("%d dates but %d values; truncated to %d", len(ts.Dates), len(ts.Values), n),
+			Repaired: true,
+		})
+	}
+
+	type point struct {
+		date        time.Time
+		value       float64
+		preliminary bool
+	}
+	points := make([]point, 0, n)
+	for i := 0; i < n; i++ {
+		if !validValue(ts.Values[i]) {
+			issues = append(issues, DataIssue{
+				Kind:     IssueInvalidValue,
+				Date:     ts.Dates[i],
+				Message:  "non-finite value dropped",
+				Repaired: true,
+			})
+			continue
+		}
+		points = append(points, point{ts.Dates[i], ts.Values[i], ts.IsPreliminary(i)})
+	}
+
+	byDate := func(i, j int) bool { return points[i].date.Before(points[j].date) }
+	if !sort.SliceIsSorted(points, byDate) {
+		sort.SliceStable(points, byDate)
+		issues = append(issues, DataIssue{
+			Kind:     IssueOutOfOrder,
+			Date:     points[0].date,
+			Message:  "observations were out of order and have been sorted",
+			Repaired: true,
+		})
+	}
+
+	clean := TimeSeries{
+		Dates:  make([]time.Time, 0, len(points)),
+		Values: make([]float64, 0, len(points)),
+	}
+	if ts.Preliminary != nil {
+		clean.Preliminary = make([]bool, 0, len(points))
+	}
+	for _, p := range points {
+		if last := len(clean.Dates) - 1; last >= 0 && clean.Dates[last].Equal(p.date) {
+			issues = append(issues, DataIssue{
+				Kind:     IssueDuplicate,
+				Date:     p.date,
+				Message:  "duplicate observation dropped",
+				Repaired: true,
+			})
+			clean.Values[last] = p.value
+			if clean.Preliminary != nil {
+				clean.Preliminary[last] = p.preliminary
+			}
+			continue
+		}
+		clean.appendPoint(p.date, p.value, p.preliminary)
+	}
+
+	return clean, issues
+}
+
+// validateUniversePrices validates every price series, keying issues by ticker.
+// A new map is returned so cached series are left untouched.
+func validateUniversePrices(u config.SectorUniverse, prices SectorPrices, issues DataIssues, progress ProgressFunc) SectorPrices {
+	tickers := map[string]string{"_benchmark": u.Benchmark}
+	calendars := map[string]*calendar.Calendar{"_benchmark": exchangeCalendar(u.Currency)}
+	for _, def := range u.Sectors {
+		tickers[def.Name] = def.ETF
+		calendars[def.Name] = exchangeCalendar(u.SectorCurrency(def))
+	}
+
+	validated := make(SectorPrices, len(prices))
+	for key, series := range prices {
+		ticker := tickers[key]
+		if ticker == "" {
+			ticker = key
+		}
+		cal := calendars[key]
+		if cal == nil {
+			cal = calendar.Weekdays
+		}
+		clean, found := ValidatePriceSeries(series, cal)
+		validated[key] = clean
+		issues.record(ticker, found, progress)
+	}
+	return validated
+}
+
+// exchangeCalendar returns the trading calendar for a listing currency.
+// Only US listings have an exchange calendar.
+func exchangeCalendar(currency string) *calendar.Calendar {
+	if currency == "USD" {
+		return calendar.NYSE
+	}
+	return calendar.Weekdays
+}
+
+// validateMacroData validates FRED series, keying issues by series ID.
+func validateMacroData(macro MacroData, issues DataIssues, progress ProgressFunc) MacroData {
+	validated := make(MacroData, len(macro))
+	for name, ts := range macro {
+		id := config.FREDSeries[name]
+		if id == "" {
+			id = name
+		}
+		clean, found := ValidateTimeSeries(ts)
+		validated[name] = clean
+		issues.record(id, found, progress)
+	}
+	return validated
+}
+
+// validateEmploymentData validates BLS series, keying issues by series ID.
+func validateEmploymentData(u config.SectorUniverse, employment EmploymentData, issues DataIssues, progress ProgressFunc) EmploymentData {
+	validated := make(EmploymentData, len(employment))
+	for sector, ts := range employment {
+		id := sector
+		if def, ok := u.Sector(sector); ok && def.BLSSeries != "" {
+			id = def.BLSSeries
+		}
+		clean, found := ValidateTimeSeries(ts)
+		validated[sector] = clean
+		if _, seen := issues[id]; !seen {
+			issues.record(id, found, progress)
+		}
+	}
+	return validated
+}
+
+// record stores issues for a series and reports a warning when any were found.
+func (d DataIssues) record(id string, found []DataIssue, progress ProgressFunc) {
+	if len(found) == 0 {
+		return
+	}
+	d[id] = found
+
+	repaired := 0
+	for _, issue := range found {
+		if issue.Repaired {
+			repaired++
+		}
+	}
+	report(progress, SourceValidation, id, StageWarning,
+		fmt.Sprintf("%s: %d data issues (%d repaired)", id, len(found), repaired))
+}
+
+// validValue reports whether v is a finite number.
+func validValue(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
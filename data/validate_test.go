@@ -0,0 +1,141 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"sector-analyzer/calendar"
+)
+
+// bar is a session dated 2024-06-DD at noon UTC, as fetched bars are.
+func bar(day int, close float64) PriceBar {
+	return PriceBar{
+		Date:     time.Date(2024, 6, day, 12, 0, 0, 0, time.UTC),
+		Close:    close,
+		AdjClose: close,
+		Volume:   1000,
+	}
+}
+
+// describe summarizes a series as "DD:close" strings and issues as
+// "kind DD repaired" strings, for comparison.
+func describe(series PriceSeries, issues []DataIssue) ([]string, []string) {
+	var bars, found []string
+	for _, b := range series {
+		bars = append(bars, fmt.Sprintf("%02d:%g", b.Date.Day(), b.Close))
+	}
+	for _, issue := range issues {
+		s := fmt.Sprintf("%s %02d", issue.Kind, issue.Date.Day())
+		if issue.Repaired {
+			s += " repaired"
+		}
+		found = append(found, s)
+	}
+	return bars, found
+}
+
+func TestValidatePriceSeries(t *testing.T) {
+	tests := []struct {
+		name   string
+		cal    *calendar.Calendar
+		series PriceSeries
+		bars   []string
+		issues []string
+	}{
+		{
+			name:   "clean week",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(5, 102), bar(6, 103), bar(7, 104)},
+			bars:   []string{"03:100", "04:101", "05:102", "06:103", "07:104"},
+		},
+		{
+			name:   "duplicate bar keeps the last",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(4, 101.5), bar(5, 102)},
+			bars:   []string{"03:100", "04:101.5", "05:102"},
+			issues: []string{"duplicate 04 repaired"},
+		},
+		{
+			name:   "out of order bars are sorted",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(5, 102), bar(4, 101)},
+			bars:   []string{"03:100", "04:101", "05:102"},
+			issues: []string{"out_of_order 03 repaired"},
+		},
+		{
+			name:   "one-bar spike that reverts",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(5, 150), bar(6, 102), bar(7, 103)},
+			bars:   []string{"03:100", "04:101", "06:102", "07:103"},
+			issues: []string{"implausible_move 05 repaired"},
+		},
+		{
+			name:   "a move that persists is kept",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(5, 150), bar(6, 151), bar(7, 152)},
+			bars:   []string{"03:100", "04:101", "05:150", "06:151", "07:152"},
+			issues: []string{"implausible_move 05"},
+		},
+		{
+			name:   "invalid close",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(5, 0), bar(6, 103)},
+			bars:   []string{"03:100", "04:101", "06:103"},
+			issues: []string{"invalid_value 05 repaired"},
+		},
+		{
+			name:   "bar on a holiday",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(17, 100), bar(18, 101), bar(19, 101), bar(20, 102), bar(21, 103)},
+			bars:   []string{"17:100", "18:101", "20:102", "21:103"},
+			issues: []string{"closed_market 19 repaired"},
+		},
+		{
+			name:   "bar on a weekend",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(7, 100), bar(8, 100), bar(10, 101)},
+			bars:   []string{"07:100", "10:101"},
+			issues: []string{"closed_market 08 repaired"},
+		},
+		{
+			name:   "real gap",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(7, 102), bar(10, 103)},
+			bars:   []string{"03:100", "04:101", "07:102", "10:103"},
+			issues: []string{"missing_trading_days 04"},
+		},
+		{
+			name:   "holiday is not a gap",
+			cal:    calendar.NYSE,
+			series: PriceSeries{bar(17, 100), bar(18, 101), bar(20, 102)},
+			bars:   []string{"17:100", "18:101", "20:102"},
+		},
+		{
+			name:   "short gap on an approximate calendar",
+			cal:    calendar.Weekdays,
+			series: PriceSeries{bar(3, 100), bar(4, 101), bar(7, 102)},
+			bars:   []string{"03:100", "04:101", "07:102"},
+		},
+		{
+			name:   "long gap on an approximate calendar",
+			cal:    calendar.Weekdays,
+			series: PriceSeries{bar(3, 100), bar(10, 101)},
+			bars:   []string{"03:100", "10:101"},
+			issues: []string{"missing_trading_days 03"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clean, issues := ValidatePriceSeries(tt.series, tt.cal)
+			bars, found := describe(clean, issues)
+			if !reflect.DeepEqual(bars, tt.bars) {
+				t.Errorf("series = %v, want %v", bars, tt.bars)
+			}
+			if !reflect.DeepEqual(found, tt.issues) {
+				t.Errorf("issues = %v, want %v", found, tt.issues)
+			}
+		})
+	}
+}
@@ -11,12 +11,17 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"sector-analyzer/analysis"
 	"sector-analyzer/api"
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+	"sector-analyzer/storage"
 )
 
 //go:embed static/*
@@ -29,6 +34,53 @@ func main() {
 		port = "8000"
 	}
 
+	// Reject unknown scoring options before serving anything
+	if err := config.ValidateOptions(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Load the sector universe before anything is fetched
+	configPath, err := config.LoadSectorConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if configPath != "" {
+		fmt.Printf("Loaded sector config from %s (universes: %s)\n",
+			configPath, strings.Join(config.UniverseNames(), ", "))
+	}
+
+	// Custom expression signals join the built-in score components
+	signalPath, err := config.LoadSignalConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := analysis.RegisterExpressionSignals(config.CustomSignals); err != nil {
+		log.Fatalf("invalid signal config %s: %v", signalPath, err)
+	}
+	if signalPath != "" {
+		fmt.Printf("Loaded signal config from %s (signals: %s)\n",
+			signalPath, strings.Join(analysis.SignalNames(), ", "))
+	}
+
+	// Persist fetched histories and score snapshots
+	db, err := storage.Open(config.DatabasePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	data.GlobalStore = db
+	api.SetDatabase(db)
+	fmt.Printf("Using database %s\n", config.DatabasePath)
+
+	// Optionally refresh all universes after each NYSE close
+	if after := os.Getenv("REFRESH_AFTER_CLOSE"); after != "" {
+		delay, err := time.ParseDuration(after)
+		if err != nil {
+			log.Fatalf("invalid REFRESH_AFTER_CLOSE %q: %v", after, err)
+		}
+		api.StartScheduler(delay)
+	}
+
 	r := chi.NewRouter()
 
 	// Middleware
@@ -53,9 +105,35 @@ func main() {
 		r.Get("/scores/summary", api.GetSummaryHandler)
 		r.Get("/scores/{sector}", api.GetSectorScoreHandler)
 
+		// Valuation
+		r.Get("/valuation/erp", api.GetERPHandler)
+
+		// Technicals
+		r.Get("/technicals/{sector}", api.GetTechnicalsHandler)
+
+		// Analytics
+		r.Get("/analytics/correlations", api.GetCorrelationsHandler)
+
+		// Portfolio
+		r.Get("/portfolio", api.GetPortfolioHandler)
+		r.Post("/portfolio/rebalance", api.RebalanceHandler)
+
+		// Scenarios
+		r.Get("/scenarios", api.GetScenariosHandler)
+		r.Get("/scenarios/stress", api.GetStressHandler)
+		r.Get("/scenarios/replay", api.GetReplayHandler)
+
+		// Refresh job endpoints
+		r.Post("/refresh", api.StartRefreshHandler)
+		r.Get("/refresh/{id}", api.GetRefreshJobHandler)
+		r.Get("/refresh/{id}/events", api.RefreshEventsHandler)
+
 		// Data endpoints
 		r.Get("/data/sectors", api.GetSectorsHandler)
+		r.Get("/data/universes", api.GetUniversesHandler)
+		r.Get("/data/signals", api.GetSignalsHandler)
 		r.Get("/data/quality", api.GetDataQualityHandler)
+		r.Get("/data/holdings", api.GetHoldingsHandler)
 
 		// Cache endpoints
 		r.Get("/cache/info", api.GetCacheInfoHandler)
@@ -85,7 +163,20 @@ func main() {
 	fmt.Println("  GET  /api/scores      - Get all sector scores")
 	fmt.Println("  GET  /api/scores/summary - Get summary report")
 	fmt.Println("  GET  /api/scores/{sector} - Get single sector score")
+	fmt.Println("  GET  /api/technicals/{sector} - Technical indicators for charting")
+	fmt.Println("  GET  /api/analytics/correlations - Sector correlations and clusters")
+	fmt.Println("  GET  /api/portfolio   - Target sector weights")
+	fmt.Println("  POST /api/portfolio/rebalance - Trades from current to target weights")
+	fmt.Println("  GET  /api/scenarios   - Scenario factors, presets and sector exposures")
+	fmt.Println("  GET  /api/scenarios/stress - Project a factor shock onto sectors")
+	fmt.Println("  GET  /api/scenarios/replay - Replay historical windows")
+	fmt.Println("  POST /api/refresh     - Start a background data refresh")
+	fmt.Println("  GET  /api/refresh/{id} - Refresh job status")
+	fmt.Println("  GET  /api/refresh/{id}/events - Stream refresh progress (SSE)")
 	fmt.Println("  GET  /api/data/sectors - List all sectors")
+	fmt.Println("  GET  /api/data/universes - List sector universes")
+	fmt.Println("  GET  /api/data/signals - List scoring signals")
+	fmt.Println("  GET  /api/data/holdings - ETF holdings look-through")
 	fmt.Println("  GET  /api/cache/info  - Cache statistics")
 	fmt.Println("  POST /api/cache/clear - Clear cache")
 
@@ -0,0 +1,137 @@
+// Constrained mean-variance optimization.
+
+package portfolio
+
+import (
+	"fmt"
+	"math"
+)
+
+// meanVariance maximizes mu'w - RiskAversion/2 w'Σw subject to full
+// investment and MinWeight <= w <= MaxWeight. With a MaxTrackingError, active
+// risk (w-b)'Σ(w-b) is penalized too, and the penalty is raised by bisection
+// until tracking error is within the limit: the smallest penalty that
+// satisfies the limit gives the best mean-variance trade-off that does.
+func meanVariance(mu []float64, cov [][]float64, benchmark []float64, opts Options) ([]float64, error) {
+	n := len(mu)
+	lo, hi := opts.MinWeight, opts.MaxWeight
+	if lo < 0 || hi <= 0 || lo > hi {
+		return nil, fmt.Errorf("invalid weight bounds [%g, %g]", lo, hi)
+	}
+	if float64(n)*lo > 1+1e-9 || float64(n)*hi < 1-1e-9 {
+		return nil, fmt.Errorf("weight bounds [%g, %g] cannot sum to 1 across %d sectors", lo, hi, n)
+	}
+
+	weights := optimize(mu, cov, benchmark, opts.RiskAversion, 0, lo, hi)
+	if opts.MaxTrackingError <= 0 || trackingError(weights, benchmark, cov) <= opts.MaxTrackingError {
+		return weights, nil
+	}
+
+	// The closest feasible portfolio to the benchmark bounds what the limit
+	// can achieve
+	closest := project(benchmark, lo, hi)
+	if te := trackingError(closest, benchmark, cov); te > opts.MaxTrackingError {
+		return nil, fmt.Errorf("tracking error limit %.4f is below %.4f, the minimum within the weight bounds",
+			opts.MaxTrackingError, te)
+	}
+
+	// Grow the penalty until the limit is met, then bisect
+	low, high := 0.0, 1.0
+	for trackingError(optimize(mu, cov, benchmark, opts.RiskAversion, high, lo, hi), benchmark, cov) > opts.MaxTrackingError {
+		low, high = high, high*4
+		if high > 1e8 {
+			return closest, nil
+		}
+	}
+	for i := 0; i < 50; i++ {
+		mid := (low + high) / 2
+		if trackingError(optimize(mu, cov, benchmark, opts.RiskAversion, mid, lo, hi), benchmark, cov) > opts.MaxTrackingError {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return optimize(mu, cov, benchmark, opts.RiskAversion, high, lo, hi), nil
+}
+
+// optimize maximizes mu'w - λ/2 w'Σw - γ/2 (w-b)'Σ(w-b) over the bounded
+// simplex by projected gradient ascent. The objective is concave, so the
+// iteration converges to the global optimum.
+func optimize(mu []float64, cov [][]float64, benchmark []float64, lambda, gamma, lo, hi float64) []float64 {
+	n := len(mu)
+
+	// The trace bounds the largest eigenvalue of Σ, giving a safe step size
+	var trace float64
+	for i := 0; i < n; i++ {
+		trace += cov[i][i]
+	}
+	step := 1.0
+	if curvature := (lambda + gamma) * trace; curvature > 0 {
+		step = 1 / curvature
+	}
+
+	weights := project(benchmark, lo, hi)
+	next := make([]float64, n)
+	for iter := 0; iter < 20000; iter++ {
+		for i := 0; i < n; i++ {
+			var risk, active float64
+			for j := 0; j < n; j++ {
+				risk += cov[i][j] * weights[j]
+				active += cov[i][j] * (weights[j] - benchmark[j])
+			}
+			next[i] = weights[i] + step*(mu[i]-lambda*risk-gamma*active)
+		}
+		next = project(next, lo, hi)
+
+		var change float64
+		for i := range weights {
+			change = math.Max(change, math.Abs(next[i]-weights[i]))
+		}
+		weights, next = next, weights
+		if change < 1e-12 {
+			break
+		}
+	}
+	return weights
+}
+
+// project returns the Euclidean projection of v onto {w : Σw = 1,
+// lo <= w <= hi}: w = clip(v - τ), with τ found by bisection.
+func project(v []float64, lo, hi float64) []float64 {
+	sum := func(tau float64) float64 {
+		var s float64
+		for _, x := range v {
+			s += math.Min(math.Max(x-tau, lo), hi)
+		}
+		return s
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, x := range v {
+		low = math.Min(low, x-hi)
+		high = math.Max(high, x-lo)
+	}
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if sum(mid) > 1 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	tau := (low + high) / 2
+	w := make([]float64, len(v))
+	for i, x := range v {
+		w[i] = math.Min(math.Max(x-tau, lo), hi)
+	}
+	return w
+}
+
+func trackingError(weights, benchmark []float64, cov [][]float64) float64 {
+	active := make([]float64, len(weights))
+	for i := range weights {
+		active[i] = weights[i] - benchmark[i]
+	}
+	return math.Sqrt(math.Max(variance(active, cov), 0))
+}
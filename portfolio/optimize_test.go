@@ -0,0 +1,154 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+// testCov is the covariance of four sectors with annual volatilities of 15%,
+// 20%, 25% and 30% and a pairwise correlation of 0.5.
+func testCov() [][]float64 {
+	vols := []float64{0.15, 0.20, 0.25, 0.30}
+	cov := make([][]float64, len(vols))
+	for i := range vols {
+		cov[i] = make([]float64, len(vols))
+		for j := range vols {
+			corr := 0.5
+			if i == j {
+				corr = 1
+			}
+			cov[i][j] = corr * vols[i] * vols[j]
+		}
+	}
+	return cov
+}
+
+// checkWeights fails unless weights sum to one within [lo, hi].
+func checkWeights(t *testing.T, weights []float64, lo, hi float64) {
+	t.Helper()
+	var sum float64
+	for i, w := range weights {
+		if w < lo-1e-9 || w > hi+1e-9 {
+			t.Errorf("weight %d = %g, outside [%g, %g]", i, w, lo, hi)
+		}
+		sum += w
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("weights sum to %g, want 1", sum)
+	}
+}
+
+func TestProject(t *testing.T) {
+	tests := []struct {
+		name   string
+		v      []float64
+		lo, hi float64
+		want   []float64 // nil to check the constraints only
+	}{
+		{"feasible point is unchanged", []float64{0.5, 0.3, 0.2}, 0, 1, []float64{0.5, 0.3, 0.2}},
+		{"excess is shifted evenly", []float64{0.6, 0.3, 0.4}, 0, 1, []float64{0.5, 0.2, 0.3}},
+		{"upper bound binds", []float64{1, 0, 0}, 0, 0.5, []float64{0.5, 0.25, 0.25}},
+		{"lower bound binds", []float64{2, -1, -1, 0}, 0.1, 1, []float64{0.7, 0.1, 0.1, 0.1}},
+		{"negative input", []float64{-3, -2, -1}, 0, 1, nil},
+		{"tight bounds", []float64{5, 1, 0, 0, 0}, 0.15, 0.25, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := project(tt.v, tt.lo, tt.hi)
+			checkWeights(t, got, tt.lo, tt.hi)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("project(%v) = %v, want %v", tt.v, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMeanVarianceTrackingError(t *testing.T) {
+	cov := testCov()
+	mu := []float64{0.02, 0.04, 0.10, 0.03}
+	benchmark := []float64{0.25, 0.25, 0.25, 0.25}
+
+	tests := []struct {
+		name     string
+		maxTE    float64
+		lo, hi   float64
+		wantBind bool // the unconstrained optimum exceeds the limit
+	}{
+		{"no limit", 0, 0, 1, false},
+		{"loose limit", 0.5, 0, 1, false},
+		{"5% limit", 0.05, 0, 1, true},
+		{"2% limit", 0.02, 0, 1, true},
+		{"2% limit with bounds", 0.02, 0.05, 0.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{MinWeight: tt.lo, MaxWeight: tt.hi, RiskAversion: 2, MaxTrackingError: tt.maxTE}
+			weights, err := meanVariance(mu, cov, benchmark, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkWeights(t, weights, tt.lo, tt.hi)
+			if tt.maxTE <= 0 {
+				return
+			}
+
+			te := trackingError(weights, benchmark, cov)
+			if te > tt.maxTE+1e-6 {
+				t.Errorf("tracking error %g exceeds the %g limit", te, tt.maxTE)
+			}
+			// The bisection finds the smallest sufficient penalty, so a
+			// binding limit is met almost exactly
+			if tt.wantBind && te < tt.maxTE*0.99 {
+				t.Errorf("tracking error %g is well inside the %g limit", te, tt.maxTE)
+			}
+		})
+	}
+}
+
+func TestMeanVarianceErrors(t *testing.T) {
+	cov := testCov()
+	mu := []float64{0.02, 0.04, 0.10, 0.03}
+
+	tests := []struct {
+		name      string
+		benchmark []float64
+		opts      Options
+	}{
+		{"inverted bounds", []float64{0.25, 0.25, 0.25, 0.25}, Options{MinWeight: 0.5, MaxWeight: 0.2, RiskAversion: 2}},
+		{"bounds cannot sum to one", []float64{0.25, 0.25, 0.25, 0.25}, Options{MinWeight: 0, MaxWeight: 0.2, RiskAversion: 2}},
+		{"limit below the bounds' minimum", []float64{0.7, 0.1, 0.1, 0.1}, Options{MinWeight: 0, MaxWeight: 0.4, RiskAversion: 2, MaxTrackingError: 0.001}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := meanVariance(mu, cov, tt.benchmark, tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestInverseVol(t *testing.T) {
+	tests := []struct {
+		name string
+		vols []float64
+		want []float64
+	}{
+		{"proportional to inverse volatility", []float64{0.1, 0.2, 0.4}, []float64{4.0 / 7, 2.0 / 7, 1.0 / 7}},
+		{"zero volatility gets no weight", []float64{0.1, 0, 0.1}, []float64{0.5, 0, 0.5}},
+		{"all zero falls back to equal weights", []float64{0, 0, 0, 0}, []float64{0.25, 0.25, 0.25, 0.25}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inverseVol(tt.vols)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-12 {
+					t.Errorf("inverseVol(%v) = %v, want %v", tt.vols, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package portfolio provides target sector weights built from opportunity scores.
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"sector-analyzer/analysis"
+	"sector-analyzer/config"
+)
+
+// Options configures portfolio construction. TopN limits "top_n" and "score"
+// to the highest-ranked sectors (0 uses every sector). MinWeight, MaxWeight,
+// RiskAversion and MaxTrackingError apply to "mean_variance"; a
+// MaxTrackingError of 0 leaves active risk unconstrained. Benchmark weights
+// default to an equal weight in every sector.
+type Options struct {
+	TopN             int
+	MinWeight        float64
+	MaxWeight        float64
+	RiskAversion     float64
+	MaxTrackingError float64
+	Benchmark        map[string]float64
+}
+
+// DefaultOptions returns the configured construction defaults.
+func DefaultOptions() Options {
+	return Options{
+		TopN:         config.PortfolioTopN,
+		MinWeight:    0,
+		MaxWeight:    1,
+		RiskAversion: config.RiskAversion,
+	}
+}
+
+// SectorWeight is a sector's target weight and its share of portfolio risk.
+type SectorWeight struct {
+	Sector           string  `json:"sector"`
+	Weight           float64 `json:"weight"`
+	BenchmarkWeight  float64 `json:"benchmark_weight"`
+	Score            float64 `json:"score"`
+	Rank             int     `json:"rank"`
+	ExpectedReturn   float64 `json:"expected_return"`
+	Volatility       float64 `json:"volatility"`
+	RiskContribution float64 `json:"risk_contribution"`
+}
+
+// Allocation is a constructed portfolio and its ex-ante risk, from the
+// annualized covariance of sector returns. ExpectedReturn is the
+// score-implied return relative to the average sector (see ExpectedReturns).
+type Allocation struct {
+	Method               string         `json:"method"`
+	Weights              []SectorWeight `json:"weights"`
+	ExpectedReturn       float64        `json:"expected_return"`
+	Volatility           float64        `json:"volatility"`
+	TrackingError        float64        `json:"tracking_error"`
+	DiversificationRatio float64        `json:"diversification_ratio"`
+}
+
+// Build constructs target weights for the sectors of returns using method,
+// one of config.PortfolioMethods. Scored sectors without returns are left
+// out, since their risk is unknown.
+func Build(method string, scores []analysis.SectorScore, returns analysis.SectorReturns, opts Options) (Allocation, error) {
+	sectors := returns.Sectors
+	n := len(sectors)
+	if n < 2 || len(returns.Returns) < 2 {
+		return Allocation{}, fmt.Errorf("need returns for at least two sectors")
+	}
+	cov := returns.Covariance()
+	vols := analysis.Volatilities(cov)
+
+	bySector := make(map[string]analysis.SectorScore, len(scores))
+	for _, s := range scores {
+		bySector[s.Sector] = s
+	}
+	scoreValues := make([]float64, n)
+	for i, sector := range sectors {
+		scoreValues[i] = getScore(bySector, sector)
+	}
+	mu := ExpectedReturns(scoreValues, vols)
+	benchmark := benchmarkWeights(sectors, opts.Benchmark)
+
+	var weights []float64
+	var err error
+	switch method {
+	case "top_n":
+		weights = topN(sectors, scoreValues, opts.TopN, func(float64) float64 { return 1 })
+	case "score":
+		weights = topN(sectors, scoreValues, opts.TopN, func(s float64) float64 { return s })
+	case "inverse_vol":
+		weights = inverseVol(vols)
+	case "risk_parity":
+		weights = riskParity(cov)
+	case "mean_variance":
+		weights, err = meanVariance(mu, cov, benchmark, opts)
+	default:
+		err = fmt.Errorf("unknown portfolio method %q", method)
+	}
+	if err != nil {
+		return Allocation{}, err
+	}
+
+	return describe(method, sectors, weights, benchmark, scoreValues, bySector, mu, cov), nil
+}
+
+// ExpectedReturns converts scores to annualized active returns with
+// Grinold's rule, alpha = IC x volatility x z-score, using config.ScoreIC as
+// the information coefficient of the opportunity score.
+func ExpectedReturns(scores, vols []float64) []float64 {
+	var mean, sd float64
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(len(scores))
+	for _, s := range scores {
+		sd += (s - mean) * (s - mean)
+	}
+	sd = math.Sqrt(sd / float64(len(scores)))
+
+	mu := make([]float64, len(scores))
+	if sd == 0 {
+		return mu
+	}
+	for i, s := range scores {
+		mu[i] = config.ScoreIC * vols[i] * (s - mean) / sd
+	}
+	return mu
+}
+
+// topN weights the n highest-scoring sectors by weight(score), normalized.
+func topN(sectors []string, scores []float64, n int, weight func(float64) float64) []float64 {
+	order := make([]int, len(sectors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	if n <= 0 || n > len(order) {
+		n = len(order)
+	}
+
+	weights := make([]float64, len(sectors))
+	var total float64
+	for _, i := range order[:n] {
+		weights[i] = math.Max(weight(scores[i]), 0)
+		total += weights[i]
+	}
+	for _, i := range order[:n] {
+		if total > 0 {
+			weights[i] /= total
+		} else {
+			weights[i] = 1 / float64(n)
+		}
+	}
+	return weights
+}
+
+// inverseVol weights each sector by the inverse of its volatility, or
+// equally when no sector has a volatility.
+func inverseVol(vols []float64) []float64 {
+	weights := make([]float64, len(vols))
+	var total float64
+	for i, v := range vols {
+		if v > 0 {
+			weights[i] = 1 / v
+			total += weights[i]
+		}
+	}
+	for i := range weights {
+		if total == 0 {
+			weights[i] = 1 / float64(len(weights))
+		} else {
+			weights[i] /= total
+		}
+	}
+	return weights
+}
+
+// riskParity finds the weights at which every sector contributes equally to
+// portfolio variance, by cyclical coordinate descent on the equal risk
+// contribution problem.
+func riskParity(cov [][]float64) []float64 {
+	n := len(cov)
+	budget := 1 / float64(n)
+	weights := inverseVol(analysis.Volatilities(cov))
+
+	for iter := 0; iter < 500; iter++ {
+		var change float64
+		for i := 0; i < n; i++ {
+			if cov[i][i] <= 0 {
+				continue
+			}
+			var c float64
+			for j := 0; j < n; j++ {
+				if j != i {
+					c += cov[i][j] * weights[j]
+				}
+			}
+			// Positive root of cov[i][i]*w^2 + c*w - budget*vol = 0
+			vol := math.Sqrt(variance(weights, cov))
+			w := (-c + math.Sqrt(c*c+4*cov[i][i]*budget*vol)) / (2 * cov[i][i])
+			change = math.Max(change, math.Abs(w-weights[i]))
+			weights[i] = w
+		}
+		if change < 1e-10 {
+			break
+		}
+	}
+	return normalize(weights)
+}
+
+// describe assembles an allocation with its risk decomposition.
+func describe(method string, sectors []string, weights, benchmark, scores []float64,
+	bySector map[string]analysis.SectorScore, mu []float64, cov [][]float64) Allocation {
+	vols := analysis.Volatilities(cov)
+	portfolioVariance := variance(weights, cov)
+
+	alloc := Allocation{
+		Method:               method,
+		Volatility:           math.Sqrt(portfolioVariance),
+		TrackingError:        trackingError(weights, benchmark, cov),
+		DiversificationRatio: analysis.DiversificationRatio(weights, cov),
+	}
+	for i, sector := range sectors {
+		var marginal float64
+		for j := range weights {
+			marginal += cov[i][j] * weights[j]
+		}
+		sw := SectorWeight{
+			Sector:          sector,
+			Weight:          roundWeight(weights[i]),
+			BenchmarkWeight: roundWeight(benchmark[i]),
+			Score:           scores[i],
+			Rank:            bySector[sector].Rank,
+			ExpectedReturn:  mu[i],
+			Volatility:      vols[i],
+		}
+		if portfolioVariance > 0 && weights[i] != 0 {
+			sw.RiskContribution = weights[i] * marginal / portfolioVariance
+		}
+		alloc.ExpectedReturn += weights[i] * mu[i]
+		alloc.Weights = append(alloc.Weights, sw)
+	}
+	sort.SliceStable(alloc.Weights, func(a, b int) bool { return alloc.Weights[a].Weight > alloc.Weights[b].Weight })
+	return alloc
+}
+
+// benchmarkWeights returns the benchmark weight of each sector, normalized
+// over the sectors present, or equal weights when none are given.
+func benchmarkWeights(sectors []string, given map[string]float64) []float64 {
+	weights := make([]float64, len(sectors))
+	var total float64
+	for i, sector := range sectors {
+		weights[i] = math.Max(given[sector], 0)
+		total += weights[i]
+	}
+	if total == 0 {
+		for i := range weights {
+			weights[i] = 1 / float64(len(sectors))
+		}
+		return weights
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+	return weights
+}
+
+func variance(weights []float64, cov [][]float64) float64 {
+	var v float64
+	for i, wi := range weights {
+		for j, wj := range weights {
+			v += wi * wj * cov[i][j]
+		}
+	}
+	return v
+}
+
+func normalize(weights []float64) []float64 {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	if total > 0 {
+		for i := range weights {
+			weights[i] /= total
+		}
+	}
+	return weights
+}
+
+// roundWeight rounds to basis points, clearing optimizer noise.
+func roundWeight(w float64) float64 {
+	return math.Round(w*10000) / 10000
+}
+
+func getScore(scores map[string]analysis.SectorScore, sector string) float64 {
+	if s, ok := scores[sector]; ok {
+		return s.OpportunityScore
+	}
+	return 50.0
+}
@@ -0,0 +1,169 @@
+// Rebalancing trades with a transaction cost model.
+
+package portfolio
+
+import (
+	"math"
+	"sort"
+
+	"sector-analyzer/config"
+)
+
+// CostModel estimates what a trade costs: half the bid-ask spread, paid on
+// every trade, plus a commission proportional to trade value with a minimum
+// per trade. Both rates are in basis points of trade value.
+type CostModel struct {
+	SpreadBps     float64 `json:"spread_bps"`
+	CommissionBps float64 `json:"commission_bps"`
+	MinCommission float64 `json:"min_commission"`
+}
+
+// DefaultCostModel returns the configured trading costs.
+func DefaultCostModel() CostModel {
+	return CostModel{
+		SpreadBps:     config.SpreadBps,
+		CommissionBps: config.CommissionBps,
+		MinCommission: config.MinCommission,
+	}
+}
+
+// Cost returns the spread cost and commission of trading value, either way.
+func (c CostModel) Cost(value float64) (spread, commission float64) {
+	value = math.Abs(value)
+	if value == 0 {
+		return 0, 0
+	}
+	spread = value * c.SpreadBps / 10000
+	commission = math.Max(value*c.CommissionBps/10000, c.MinCommission)
+	return spread, commission
+}
+
+// Position is a sector's current and target weight, and the price of its ETF.
+type Position struct {
+	Sector  string
+	ETF     string
+	Current float64
+	Target  float64
+	Price   float64
+}
+
+// Trade is the proposed trade in one sector. Value is signed, positive for
+// buys, and Shares is Value at Price rounded to whole shares. Sectors inside
+// the no-trade band are "hold" and keep their current weight.
+type Trade struct {
+	Sector        string  `json:"sector"`
+	ETF           string  `json:"etf"`
+	Action        string  `json:"action"`
+	CurrentWeight float64 `json:"current_weight"`
+	TargetWeight  float64 `json:"target_weight"`
+	FinalWeight   float64 `json:"final_weight"`
+	Value         float64 `json:"value"`
+	Price         float64 `json:"price,omitempty"`
+	Shares        int64   `json:"shares"`
+	SpreadCost    float64 `json:"spread_cost"`
+	Commission    float64 `json:"commission"`
+}
+
+// Proposal is the set of trades that moves a portfolio to its target.
+// Turnover is one-way: half the sum of absolute weight changes. CashWeight
+// is the share of the portfolio left in cash after trades and costs.
+type Proposal struct {
+	Trades     []Trade `json:"trades"`
+	Buys       float64 `json:"buys"`
+	Sells      float64 `json:"sells"`
+	Turnover   float64 `json:"turnover"`
+	SpreadCost float64 `json:"spread_cost"`
+	Commission float64 `json:"commission"`
+	TotalCost  float64 `json:"total_cost"`
+	CostBps    float64 `json:"cost_bps"`
+	CashWeight float64 `json:"cash_weight"`
+}
+
+// Rebalance proposes trades moving a portfolio of value from its current
+// weights to their targets. Weight changes no larger than band are skipped
+// to avoid churn. Current weights may sum to less than one, the rest being
+// cash. When skipped sales leave buys unfunded, buys are scaled back so the
+// portfolio, costs included, is never levered.
+func Rebalance(positions []Position, value, band float64, costs CostModel) Proposal {
+	cash := 1.0
+	changes := make([]float64, len(positions))
+	for i, p := range positions {
+		cash -= p.Current
+		if math.Abs(p.Target-p.Current) > band {
+			changes[i] = p.Target - p.Current
+		}
+	}
+
+	// Cash remaining after trading the sales and a fraction of the buys
+	remaining := func(fraction float64) float64 {
+		left := cash * value
+		for _, change := range changes {
+			trade := change * value
+			if change > 0 {
+				trade *= fraction
+			}
+			spread, commission := costs.Cost(trade)
+			left -= trade + spread + commission
+		}
+		return left
+	}
+	fraction := 1.0
+	if remaining(1) < 0 {
+		low, high := 0.0, 1.0
+		for i := 0; i < 50; i++ {
+			mid := (low + high) / 2
+			if remaining(mid) < 0 {
+				high = mid
+			} else {
+				low = mid
+			}
+		}
+		fraction = low
+	}
+
+	var proposal Proposal
+	var traded float64
+	for i, p := range positions {
+		change := changes[i]
+		if change > 0 {
+			change *= fraction
+		}
+		t := Trade{
+			Sector:        p.Sector,
+			ETF:           p.ETF,
+			Action:        "hold",
+			CurrentWeight: p.Current,
+			TargetWeight:  p.Target,
+			FinalWeight:   roundWeight(p.Current + change),
+			Value:         change * value,
+			Price:         p.Price,
+		}
+		if change > 0 {
+			t.Action = "buy"
+			proposal.Buys += t.Value
+		} else if change < 0 {
+			t.Action = "sell"
+			proposal.Sells -= t.Value
+		}
+		if p.Price > 0 {
+			t.Shares = int64(math.Round(t.Value / p.Price))
+		}
+		t.SpreadCost, t.Commission = costs.Cost(t.Value)
+		proposal.SpreadCost += t.SpreadCost
+		proposal.Commission += t.Commission
+		traded += math.Abs(change)
+		proposal.Trades = append(proposal.Trades, t)
+	}
+
+	proposal.Turnover = traded / 2
+	proposal.TotalCost = proposal.SpreadCost + proposal.Commission
+	if value > 0 {
+		proposal.CostBps = proposal.TotalCost / value * 10000
+		proposal.CashWeight = roundWeight(remaining(fraction) / value)
+	}
+	// Largest trades first, holds last
+	sort.SliceStable(proposal.Trades, func(a, b int) bool {
+		return math.Abs(proposal.Trades[a].Value) > math.Abs(proposal.Trades[b].Value)
+	})
+	return proposal
+}
@@ -0,0 +1,191 @@
+// Sector sensitivities to market and macro factors.
+
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+
+	"sector-analyzer/analysis"
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// Exposure is a sector's sensitivity to each factor: its monthly return per
+// unit move in the factor, holding the others fixed.
+type Exposure struct {
+	Sector   string             `json:"sector"`
+	Betas    map[string]float64 `json:"betas"`
+	RSquared float64            `json:"r_squared"`
+}
+
+// Model holds sector exposures estimated by regressing monthly sector
+// returns on monthly factor moves (see config.ScenarioFactors), and the
+// covariance of those moves.
+type Model struct {
+	Factors    []string    `json:"factors"`
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	Months     int         `json:"months"`
+	Exposures  []Exposure  `json:"exposures"`
+	Covariance [][]float64 `json:"covariance"`
+}
+
+// Estimate fits the model over the last months months in which the
+// benchmark, every sector and every available factor have data. Factors
+// whose macro series are missing are left out.
+func Estimate(allData *data.AllData, months int) (*Model, error) {
+	moves := factorMoves(allData)
+	var factors []string
+	for _, f := range config.ScenarioFactors {
+		if _, ok := moves[f]; ok {
+			factors = append(factors, f)
+		}
+	}
+
+	sectorReturns := make(map[string]map[time.Time]float64)
+	var sectors []string
+	for _, sector := range allData.Sectors {
+		if series := allData.SectorPrices[sector]; len(series) > 0 {
+			sectorReturns[sector] = analysis.MonthlyReturns(series)
+			sectors = append(sectors, sector)
+		}
+	}
+
+	// Months every series covers, most recent last
+	var common []time.Time
+	for month := range moves["market"] {
+		ok := true
+		for _, f := range factors {
+			_, has := moves[f][month]
+			ok = ok && has
+		}
+		for _, sector := range sectors {
+			_, has := sectorReturns[sector][month]
+			ok = ok && has
+		}
+		if ok {
+			common = append(common, month)
+		}
+	}
+	sort.Slice(common, func(i, j int) bool { return common[i].Before(common[j]) })
+	if months > 0 && len(common) > months {
+		common = common[len(common)-months:]
+	}
+	if len(sectors) == 0 || len(common) < len(factors)+config.ScenarioMinMonths {
+		return nil, fmt.Errorf("need at least %d months of sector and factor history, have %d",
+			len(factors)+config.ScenarioMinMonths, len(common))
+	}
+
+	n, k := len(common), len(factors)
+	x := mat.NewDense(n, k+1, nil)
+	y := mat.NewDense(n, len(sectors), nil)
+	for t, month := range common {
+		x.Set(t, 0, 1)
+		for j, f := range factors {
+			x.Set(t, j+1, moves[f][month])
+		}
+		for i, sector := range sectors {
+			y.Set(t, i, sectorReturns[sector][month])
+		}
+	}
+
+	// Least squares for every sector at once
+	var coef mat.Dense
+	if err := coef.Solve(x, y); err != nil {
+		return nil, fmt.Errorf("factor regression: %w", err)
+	}
+	var fitted mat.Dense
+	fitted.Mul(x, &coef)
+
+	model := &Model{
+		Factors: factors,
+		Start:   common[0],
+		End:     common[n-1],
+		Months:  n,
+	}
+	for i, sector := range sectors {
+		e := Exposure{Sector: sector, Betas: make(map[string]float64, k)}
+		for j, f := range factors {
+			e.Betas[f] = coef.At(j+1, i)
+		}
+		actual := mat.Col(nil, i, y)
+		mean := stat.Mean(actual, nil)
+		var residual, total float64
+		for t, v := range actual {
+			residual += (v - fitted.At(t, i)) * (v - fitted.At(t, i))
+			total += (v - mean) * (v - mean)
+		}
+		if total > 0 {
+			e.RSquared = 1 - residual/total
+		}
+		model.Exposures = append(model.Exposures, e)
+	}
+
+	cov := mat.NewSymDense(k, nil)
+	stat.CovarianceMatrix(cov, x.Slice(0, n, 1, k+1), nil)
+	model.Covariance = make([][]float64, k)
+	for i := range model.Covariance {
+		model.Covariance[i] = make([]float64, k)
+		for j := range model.Covariance[i] {
+			model.Covariance[i][j] = cov.At(i, j)
+		}
+	}
+	return model, nil
+}
+
+// factorMoves returns each available factor's move by month, keyed by the
+// first day of the month:
+//   - market: the benchmark's return
+//   - treasury_10y: the change in the 10-year yield, in percentage points
+//   - yield_curve: the change in the 10y-2y spread, in percentage points
+//   - inflation: the change in year-over-year CPI inflation, in points
+func factorMoves(allData *data.AllData) map[string]map[time.Time]float64 {
+	moves := make(map[string]map[time.Time]float64)
+	if series := allData.SectorPrices["_benchmark"]; len(series) > 0 {
+		moves["market"] = analysis.MonthlyReturns(series)
+	}
+
+	tenYear, ok := allData.MacroData["treasury_10y"]
+	if ok && len(tenYear.Values) > 0 {
+		levels := analysis.MonthEnds(tenYear)
+		moves["treasury_10y"] = differences(levels)
+		if twoYear, ok := allData.MacroData["treasury_2y"]; ok && len(twoYear.Values) > 0 {
+			short := analysis.MonthEnds(twoYear)
+			spread := make(map[time.Time]float64)
+			for month, v := range levels {
+				if s, ok := short[month]; ok {
+					spread[month] = v - s
+				}
+			}
+			moves["yield_curve"] = differences(spread)
+		}
+	}
+
+	if cpi, ok := allData.MacroData["cpi"]; ok && len(cpi.Values) > 0 {
+		index := analysis.MonthEnds(cpi)
+		inflation := make(map[time.Time]float64)
+		for month, v := range index {
+			if prev, ok := index[month.AddDate(-1, 0, 0)]; ok && prev > 0 {
+				inflation[month] = (v/prev - 1) * 100
+			}
+		}
+		moves["inflation"] = differences(inflation)
+	}
+	return moves
+}
+
+// differences returns each month's change from the month before.
+func differences(values map[time.Time]float64) map[time.Time]float64 {
+	changes := make(map[time.Time]float64)
+	for month, v := range values {
+		if prev, ok := values[month.AddDate(0, -1, 0)]; ok {
+			changes[month] = v - prev
+		}
+	}
+	return changes
+}
@@ -0,0 +1,166 @@
+// Historical replay of named market windows.
+
+package scenario
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"sector-analyzer/analysis"
+	"sector-analyzer/calendar"
+	"sector-analyzer/config"
+	"sector-analyzer/data"
+)
+
+// SectorReplay is a sector's return over a replayed window, and that return
+// less the benchmark's.
+type SectorReplay struct {
+	Sector   string   `json:"sector"`
+	Return   float64  `json:"return"`
+	Relative *float64 `json:"relative"`
+	Rank     int      `json:"rank"`
+}
+
+// Replay is what the sectors did over a historical window, measured from
+// the close of the last session on or before From to that on or before To.
+// Moves holds the factor moves over the window where the series cover it.
+type Replay struct {
+	config.HistoricalWindow
+	Start           string             `json:"start"`
+	End             string             `json:"end"`
+	Benchmark       *float64           `json:"benchmark"`
+	Moves           map[string]float64 `json:"moves"`
+	Sectors         []SectorReplay     `json:"sectors"`
+	PortfolioReturn *float64           `json:"portfolio_return,omitempty"`
+}
+
+// ReplayWindow measures allData's sectors over window from prices, the
+// stored history of data.FetchPriceHistory; allData's own prices only
+// cover the scoring window. It fails if the history does not reach back to
+// the window's start. Weights, if given, are a portfolio whose return is
+// reported.
+func ReplayWindow(allData *data.AllData, prices data.SectorPrices, window config.HistoricalWindow, weights map[string]float64) (Replay, error) {
+	from, err := time.Parse("2006-01-02", window.From)
+	if err != nil {
+		return Replay{}, fmt.Errorf("invalid from date %q", window.From)
+	}
+	to, err := time.Parse("2006-01-02", window.To)
+	if err != nil {
+		return Replay{}, fmt.Errorf("invalid to date %q", window.To)
+	}
+	if !to.After(from) {
+		return Replay{}, fmt.Errorf("window ends %s, before it starts", window.To)
+	}
+	from, to = sessionOnOrBefore(from), sessionOnOrBefore(to)
+
+	replay := Replay{
+		HistoricalWindow: window,
+		Start:            from.Format("2006-01-02"),
+		End:              to.Format("2006-01-02"),
+		Moves:            make(map[string]float64),
+		Sectors:          []SectorReplay{},
+	}
+	if r, ok := windowReturn(prices["_benchmark"], from, to); ok {
+		replay.Benchmark = &r
+		replay.Moves["market"] = r
+	}
+
+	var earliest time.Time
+	for _, sector := range allData.Sectors {
+		series := prices[sector]
+		r, ok := windowReturn(series, from, to)
+		if !ok {
+			if len(series) > 0 && (earliest.IsZero() || series[0].Date.After(earliest)) {
+				earliest = series[0].Date
+			}
+			continue
+		}
+		s := SectorReplay{Sector: sector, Return: r}
+		if replay.Benchmark != nil {
+			relative := r - *replay.Benchmark
+			s.Relative = &relative
+		}
+		replay.Sectors = append(replay.Sectors, s)
+	}
+	if len(replay.Sectors) == 0 {
+		if earliest.IsZero() {
+			return Replay{}, fmt.Errorf("no sector prices")
+		}
+		return Replay{}, fmt.Errorf("price history starts %s, after the window", calendar.NYSE.Date(earliest).Format("2006-01-02"))
+	}
+	sort.SliceStable(replay.Sectors, func(a, b int) bool { return replay.Sectors[a].Return > replay.Sectors[b].Return })
+	for i := range replay.Sectors {
+		replay.Sectors[i].Rank = i + 1
+	}
+
+	if tenYear, ok := levelChange(allData.MacroData["treasury_10y"], from, to); ok {
+		replay.Moves["treasury_10y"] = tenYear
+		if twoYear, ok := levelChange(allData.MacroData["treasury_2y"], from, to); ok {
+			replay.Moves["yield_curve"] = tenYear - twoYear
+		}
+	}
+
+	if len(weights) > 0 {
+		var total float64
+		for _, s := range replay.Sectors {
+			total += weights[s.Sector] * s.Return
+		}
+		replay.PortfolioReturn = &total
+	}
+	return replay, nil
+}
+
+// sessionOnOrBefore returns the NYSE session on or before a calendar date.
+func sessionOnOrBefore(date time.Time) time.Time {
+	return calendar.NYSE.LastTradingDay(time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, calendar.NYSE.Location))
+}
+
+// windowReturn returns the return of series from the close on or before
+// from to the close on or before to, or false if the series doesn't cover
+// from.
+func windowReturn(series data.PriceSeries, from, to time.Time) (float64, bool) {
+	start, ok := priceOn(series, from)
+	if !ok || start <= 0 {
+		return 0, false
+	}
+	end, ok := priceOn(series, to)
+	if !ok {
+		return 0, false
+	}
+	return end/start - 1, true
+}
+
+// priceOn returns the last price on or before date, or false if the series
+// starts after it.
+func priceOn(series data.PriceSeries, date time.Time) (float64, bool) {
+	i := sort.Search(len(series), func(i int) bool {
+		return calendar.NYSE.Date(series[i].Date).After(date)
+	}) - 1
+	if i < 0 {
+		return 0, false
+	}
+	return analysis.ReturnPrice(series[i]), true
+}
+
+// levelChange returns the change in a macro series between its last
+// observations on or before from and to.
+func levelChange(ts data.TimeSeries, from, to time.Time) (float64, bool) {
+	at := func(date time.Time) (float64, bool) {
+		limit := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		i := sort.Search(len(ts.Dates), func(i int) bool { return ts.Dates[i].After(limit) }) - 1
+		for ; i >= 0; i-- {
+			if !math.IsNaN(ts.Values[i]) {
+				return ts.Values[i], true
+			}
+		}
+		return 0, false
+	}
+	start, ok := at(from)
+	if !ok {
+		return 0, false
+	}
+	end, ok := at(to)
+	return end - start, ok
+}
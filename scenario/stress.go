@@ -0,0 +1,260 @@
+// Package scenario provides factor stress tests and historical replay of
+// sector returns.
+package scenario
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+
+	"sector-analyzer/analysis"
+	"sector-analyzer/calendar"
+	"sector-analyzer/data"
+)
+
+// Projection is a sector's projected return under a scenario, split into
+// each factor's contribution, and its opportunity ranking before and after.
+type Projection struct {
+	Sector        string             `json:"sector"`
+	Return        float64            `json:"return"`
+	Contributions map[string]float64 `json:"contributions"`
+	Score         float64            `json:"score"`
+	Rank          int                `json:"rank"`
+	ShockedScore  float64            `json:"shocked_score"`
+	ShockedRank   int                `json:"shocked_rank"`
+	RankChange    int                `json:"rank_change"`
+}
+
+// Stress is the outcome of a scenario. Moves are the factor moves applied:
+// the shocks given and, when propagated, the moves implied for the others.
+// PortfolioReturn is the weighted projected return of the given portfolio.
+type Stress struct {
+	Moves           map[string]float64 `json:"moves"`
+	Projections     []Projection       `json:"projections"`
+	PortfolioReturn *float64           `json:"portfolio_return,omitempty"`
+}
+
+// Moves returns the factor moves of a scenario. Each shock must name one of
+// the model's factors. With propagate, factors not shocked move by their
+// expectation given the shocks, from the factors' historical covariance, so
+// a rate shock also carries the equity move that has come with one;
+// otherwise they are held at zero.
+func (m *Model) Moves(shocks map[string]float64, propagate bool) (map[string]float64, error) {
+	var given, implied []int
+	for i, f := range m.Factors {
+		if _, ok := shocks[f]; ok {
+			given = append(given, i)
+		} else {
+			implied = append(implied, i)
+		}
+	}
+	for f := range shocks {
+		if !slices.Contains(m.Factors, f) {
+			return nil, fmt.Errorf("factor %q is not available (have %v)", f, m.Factors)
+		}
+	}
+
+	moves := make(map[string]float64, len(m.Factors))
+	for _, i := range implied {
+		moves[m.Factors[i]] = 0
+	}
+	for f, v := range shocks {
+		moves[f] = v
+	}
+	if !propagate || len(given) == 0 || len(implied) == 0 {
+		return moves, nil
+	}
+
+	// E[implied | given] = Σ_ig Σ_gg⁻¹ shocks
+	sgg := mat.NewSymDense(len(given), nil)
+	for a, i := range given {
+		for b, j := range given {
+			sgg.SetSym(a, b, m.Covariance[i][j])
+		}
+	}
+	shock := mat.NewVecDense(len(given), nil)
+	for a, i := range given {
+		shock.SetVec(a, shocks[m.Factors[i]])
+	}
+	var solved mat.VecDense
+	if err := solved.SolveVec(sgg, shock); err != nil {
+		return nil, fmt.Errorf("shocked factors are collinear: %w", err)
+	}
+	for _, i := range implied {
+		var v float64
+		for a, j := range given {
+			v += m.Covariance[i][j] * solved.AtVec(a)
+		}
+		moves[m.Factors[i]] = v
+	}
+	return moves, nil
+}
+
+// Project applies factor moves to every sector's exposures.
+func (m *Model) Project(moves map[string]float64) map[string]Projection {
+	projections := make(map[string]Projection, len(m.Exposures))
+	for _, e := range m.Exposures {
+		p := Projection{Sector: e.Sector, Contributions: make(map[string]float64, len(e.Betas))}
+		for f, beta := range e.Betas {
+			p.Contributions[f] = 0
+			if moves[f] != 0 {
+				p.Contributions[f] = beta * moves[f]
+				p.Return += p.Contributions[f]
+			}
+		}
+		projections[e.Sector] = p
+	}
+	return projections
+}
+
+// Run projects a scenario onto the sectors and re-scores them on data
+// shocked by it, to show how the ranking would change. Weights, if given,
+// are a portfolio whose projected return is reported.
+func (m *Model) Run(allData *data.AllData, scorer *analysis.SectorScorer, moves map[string]float64, weights map[string]float64) Stress {
+	projections := m.Project(moves)
+	returns := make(map[string]float64, len(projections))
+	for sector, p := range projections {
+		returns[sector] = p.Return
+	}
+
+	before := scorer.CalculateScores(allData)
+	after := scorer.CalculateScores(Shock(allData, moves, returns))
+	shocked := make(map[string]analysis.SectorScore, len(after))
+	for _, s := range after {
+		shocked[s.Sector] = s
+	}
+
+	stress := Stress{Moves: moves}
+	for _, s := range before {
+		p, ok := projections[s.Sector]
+		if !ok {
+			continue
+		}
+		p.Score, p.Rank = s.OpportunityScore, s.Rank
+		p.ShockedScore, p.ShockedRank = shocked[s.Sector].OpportunityScore, shocked[s.Sector].Rank
+		p.RankChange = p.Rank - p.ShockedRank
+		stress.Projections = append(stress.Projections, p)
+	}
+	sort.SliceStable(stress.Projections, func(a, b int) bool {
+		return stress.Projections[a].Return > stress.Projections[b].Return
+	})
+
+	if len(weights) > 0 {
+		var total float64
+		for sector, w := range weights {
+			total += w * returns[sector]
+		}
+		stress.PortfolioReturn = &total
+	}
+	return stress
+}
+
+// Shock returns a copy of allData as it would look the session after a
+// scenario: each sector's prices moved by its projected return, the
+// benchmark by the market move, valuation ratios repriced, and the yields
+// and CPI extended by the macro moves. The original is not modified.
+// Constituent prices and look-through fundamentals are left as they are.
+func Shock(allData *data.AllData, moves map[string]float64, returns map[string]float64) *data.AllData {
+	shocked := *allData
+
+	shocked.SectorPrices = make(data.SectorPrices, len(allData.SectorPrices))
+	for sector, series := range allData.SectorPrices {
+		r, ok := returns[sector]
+		if sector == "_benchmark" {
+			r, ok = moves["market"]
+		}
+		if !ok || len(series) == 0 {
+			shocked.SectorPrices[sector] = series
+			continue
+		}
+		shocked.SectorPrices[sector] = appendBar(series, r)
+	}
+
+	shocked.SectorInfo = make(map[string]data.SectorInfo, len(allData.SectorInfo))
+	for sector, info := range allData.SectorInfo {
+		if r, ok := returns[sector]; ok {
+			info.ForwardPE = scaled(info.ForwardPE, 1+r)
+			info.TrailingPE = scaled(info.TrailingPE, 1+r)
+			info.PriceToBook = scaled(info.PriceToBook, 1+r)
+			info.PriceToSales = scaled(info.PriceToSales, 1+r)
+			info.DividendYield = scaled(info.DividendYield, 1/(1+r))
+		}
+		shocked.SectorInfo[sector] = info
+	}
+
+	shocked.MacroData = make(data.MacroData, len(allData.MacroData))
+	for name, ts := range allData.MacroData {
+		shocked.MacroData[name] = ts
+	}
+	if ts, ok := shocked.MacroData["treasury_10y"]; ok {
+		shocked.MacroData["treasury_10y"] = appendLevel(ts, moves["treasury_10y"])
+	}
+	if ts, ok := shocked.MacroData["treasury_2y"]; ok {
+		shocked.MacroData["treasury_2y"] = appendLevel(ts, moves["treasury_10y"]-moves["yield_curve"])
+	}
+	if ts, ok := shocked.MacroData["cpi"]; ok {
+		shocked.MacroData["cpi"] = appendInflation(ts, moves["inflation"])
+	}
+	return &shocked
+}
+
+// appendBar returns series with a bar on the next session, r above the last.
+func appendBar(series data.PriceSeries, r float64) data.PriceSeries {
+	last := series[len(series)-1]
+	next := calendar.NYSE.NextTradingDay(last.Date)
+	bar := data.PriceBar{
+		Date:     time.Date(next.Year(), next.Month(), next.Day(), 12, 0, 0, 0, time.UTC),
+		Open:     last.Open * (1 + r),
+		High:     last.High * (1 + r),
+		Low:      last.Low * (1 + r),
+		Close:    last.Close * (1 + r),
+		AdjClose: last.AdjClose * (1 + r),
+		Volume:   last.Volume,
+	}
+	return append(slices.Clip(series), bar)
+}
+
+// appendLevel returns a daily series with an observation the day after its
+// last, change above it.
+func appendLevel(ts data.TimeSeries, change float64) data.TimeSeries {
+	n := len(ts.Values)
+	if n == 0 {
+		return ts
+	}
+	return data.TimeSeries{
+		Dates:  append(slices.Clip(ts.Dates), ts.Dates[n-1].AddDate(0, 0, 1)),
+		Values: append(slices.Clip(ts.Values), ts.Values[n-1]+change),
+	}
+}
+
+// appendInflation returns a monthly price index with the next month's value
+// set so year-over-year inflation is change points above its latest rate.
+func appendInflation(ts data.TimeSeries, change float64) data.TimeSeries {
+	n := len(ts.Values)
+	if n == 0 {
+		return ts
+	}
+	next := ts.Dates[n-1].AddDate(0, 1, 0)
+	index := analysis.MonthEnds(ts)
+	yearAgo, ok := index[analysis.MonthKey(ts.Dates[n-1].AddDate(-1, 0, 0))]
+	base, okBase := index[analysis.MonthKey(next.AddDate(-1, 0, 0))]
+	if !ok || !okBase || yearAgo <= 0 {
+		return ts
+	}
+	latest := ts.Values[n-1]/yearAgo - 1
+	return data.TimeSeries{
+		Dates:  append(slices.Clip(ts.Dates), next),
+		Values: append(slices.Clip(ts.Values), base*(1+latest+change/100)),
+	}
+}
+
+func scaled(v *float64, factor float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	s := *v * factor
+	return &s
+}
@@ -0,0 +1,194 @@
+// The database schema and its migrations.
+
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are applied in order; each entry's index+1 is its version.
+// Never edit an applied migration: append a new one instead.
+var migrations = []string{
+	// 1: fetched histories and score snapshots
+	`
+	CREATE TABLE price_history (
+		ticker     TEXT PRIMARY KEY,
+		from_date  TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE price_bars (
+		ticker      TEXT    NOT NULL REFERENCES price_history(ticker) ON DELETE CASCADE,
+		date        TEXT    NOT NULL,
+		timestamp   INTEGER NOT NULL,
+		open        REAL,
+		high        REAL,
+		low         REAL,
+		close       REAL    NOT NULL,
+		adj_close   REAL,
+		volume      INTEGER,
+		dividend    REAL,
+		split_ratio REAL,
+		PRIMARY KEY (ticker, date)
+	);
+
+	CREATE TABLE series (
+		series_id  TEXT PRIMARY KEY,
+		source     TEXT NOT NULL,
+		from_date  TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE series_observations (
+		series_id TEXT NOT NULL REFERENCES series(series_id) ON DELETE CASCADE,
+		date      TEXT NOT NULL,
+		value     REAL NOT NULL,
+		PRIMARY KEY (series_id, date)
+	);
+
+	CREATE TABLE industry_rd (
+		industry     TEXT NOT NULL,
+		fetched_at   TEXT NOT NULL,
+		rd_intensity REAL NOT NULL,
+		PRIMARY KEY (industry, fetched_at)
+	);
+
+	CREATE TABLE score_snapshots (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		universe        TEXT NOT NULL,
+		computed_at     TEXT NOT NULL,
+		data_fetched_at TEXT NOT NULL,
+		weights         TEXT NOT NULL
+	);
+	CREATE INDEX score_snapshots_universe ON score_snapshots (universe, computed_at);
+
+	CREATE TABLE sector_scores (
+		snapshot_id       INTEGER NOT NULL REFERENCES score_snapshots(id) ON DELETE CASCADE,
+		sector            TEXT    NOT NULL,
+		rank              INTEGER NOT NULL,
+		opportunity_score REAL    NOT NULL,
+		momentum_score    REAL    NOT NULL,
+		valuation_score   REAL    NOT NULL,
+		growth_score      REAL    NOT NULL,
+		innovation_score  REAL    NOT NULL,
+		macro_score       REAL    NOT NULL,
+		price_return_3mo  REAL,
+		price_return_6mo  REAL,
+		price_return_12mo REAL,
+		relative_strength REAL,
+		forward_pe        REAL,
+		employment_growth REAL,
+		rd_intensity      REAL,
+		PRIMARY KEY (snapshot_id, sector)
+	);
+	`,
+
+	// 2: FRED observation vintages (ALFRED real-time periods)
+	`
+	CREATE TABLE series_vintages (
+		series_id      TEXT NOT NULL,
+		date           TEXT NOT NULL,
+		realtime_start TEXT NOT NULL,
+		realtime_end   TEXT NOT NULL,
+		value          REAL NOT NULL,
+		PRIMARY KEY (series_id, date, realtime_start)
+	);
+	`,
+
+	// 3: BLS preliminary-value flags
+	`
+	ALTER TABLE series_observations ADD COLUMN preliminary INTEGER NOT NULL DEFAULT 0;
+	`,
+
+	// 4: archived Damodaran R&D datasets, by year of publication
+	`
+	CREATE TABLE industry_rd_archive (
+		year         INTEGER NOT NULL,
+		industry     TEXT    NOT NULL,
+		rd_intensity REAL    NOT NULL,
+		fetched_at   TEXT    NOT NULL,
+		PRIMARY KEY (year, industry)
+	);
+	`,
+
+	// 5: industry firm counts and dollar figures for revenue weighting
+	`
+	ALTER TABLE industry_rd ADD COLUMN firms INTEGER;
+	ALTER TABLE industry_rd ADD COLUMN revenue REAL;
+	ALTER TABLE industry_rd ADD COLUMN rd_expense REAL;
+	ALTER TABLE industry_rd_archive ADD COLUMN firms INTEGER;
+	ALTER TABLE industry_rd_archive ADD COLUMN revenue REAL;
+	ALTER TABLE industry_rd_archive ADD COLUMN rd_expense REAL;
+	`,
+
+	// 6: quality component score
+	`
+	ALTER TABLE sector_scores ADD COLUMN quality_score REAL;
+	`,
+
+	// 7: equity risk premium, tracked across snapshots
+	`
+	ALTER TABLE sector_scores ADD COLUMN equity_risk_premium REAL;
+	`,
+
+	// 8: breadth component score
+	`
+	ALTER TABLE sector_scores ADD COLUMN breadth_score REAL;
+	`,
+
+	// 9: scoring model, with the factor and cycle scores it combines
+	`
+	ALTER TABLE score_snapshots ADD COLUMN model TEXT;
+	ALTER TABLE sector_scores ADD COLUMN factor_score REAL;
+	ALTER TABLE sector_scores ADD COLUMN cycle_score REAL;
+	`,
+
+	// 10: every signal's score as a JSON object, for registered signals
+	// without a column of their own
+	`
+	ALTER TABLE sector_scores ADD COLUMN components TEXT;
+	`,
+}
+
+// migrate applies any migrations newer than the database's schema version,
+// each in its own transaction.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build (%d)", current, len(migrations))
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,470 @@
+// Package storage persists fetched time series and score snapshots in an
+// embedded SQLite database (pure Go, no cgo), so history survives restarts
+// and can be queried with plain SQL.
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"sector-analyzer/analysis"
+	"sector-analyzer/data"
+)
+
+const dateFormat = "2006-01-02"
+
+// DB is a SQLite-backed store. It implements data.SeriesStore.
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens (creating if needed) the database at path and applies migrations.
+func Open(path string) (*DB, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	// SQLite allows one writer; a single connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database %s: %w", path, err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// LoadPrices reads the stored history for a ticker.
+func (d *DB) LoadPrices(ticker string) (data.PriceRecord, bool, error) {
+	rec := data.PriceRecord{Ticker: ticker}
+
+	var from, updated string
+	err := d.db.QueryRow(`SELECT from_date, updated_at FROM price_history WHERE ticker = ?`, ticker).Scan(&from, &updated)
+	if err == sql.ErrNoRows {
+		return rec, false, nil
+	}
+	if err != nil {
+		return rec, false, err
+	}
+	rec.From, _ = time.Parse(dateFormat, from)
+	rec.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+
+	rows, err := d.db.Query(`
+		SELECT timestamp, open, high, low, close, adj_close, volume, dividend, split_ratio
+		FROM price_bars WHERE ticker = ? ORDER BY date`, ticker)
+	if err != nil {
+		return rec, false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts int64
+		var bar data.PriceBar
+		var adjClose, dividend, splitRatio sql.NullFloat64
+		if err := rows.Scan(&ts, &bar.Open, &bar.High, &bar.Low, &bar.Close,
+			&adjClose, &bar.Volume, &dividend, &splitRatio); err != nil {
+			return rec, false, err
+		}
+		bar.Date = time.Unix(ts, 0)
+		bar.AdjClose = adjClose.Float64
+		bar.Dividend = dividend.Float64
+		bar.SplitRatio = splitRatio.Float64
+		rec.Bars = append(rec.Bars, bar)
+	}
+	return rec, true, rows.Err()
+}
+
+// SavePrices replaces the stored history for a ticker.
+func (d *DB) SavePrices(rec data.PriceRecord) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO price_history (ticker, from_date, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (ticker) DO UPDATE SET from_date = excluded.from_date, updated_at = excluded.updated_at`,
+		rec.Ticker, rec.From.UTC().Format(dateFormat), rec.UpdatedAt.UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM price_bars WHERE ticker = ?`, rec.Ticker); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO price_bars
+			(ticker, date, timestamp, open, high, low, close, adj_close, volume, dividend, split_ratio)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, bar := range rec.Bars {
+		if _, err := stmt.Exec(rec.Ticker, bar.Date.UTC().Format(dateFormat), bar.Date.Unix(),
+			bar.Open, bar.High, bar.Low, bar.Close, nullIfZero(bar.AdjClose), bar.Volume,
+			nullIfZero(bar.Dividend), nullIfZero(bar.SplitRatio)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadSeries reads the stored history for a FRED or BLS series.
+func (d *DB) LoadSeries(id string) (data.SeriesRecord, bool, error) {
+	rec := data.SeriesRecord{ID: id}
+
+	var from, updated string
+	err := d.db.QueryRow(`SELECT source, from_date, updated_at FROM series WHERE series_id = ?`, id).
+		Scan(&rec.Source, &from, &updated)
+	if err == sql.ErrNoRows {
+		return rec, false, nil
+	}
+	if err != nil {
+		return rec, false, err
+	}
+	rec.From, _ = time.Parse(dateFormat, from)
+	rec.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+
+	rows, err := d.db.Query(`SELECT date, value, preliminary FROM series_observations WHERE series_id = ? ORDER BY date`, id)
+	if err != nil {
+		return rec, false, err
+	}
+	defer rows.Close()
+
+	anyPreliminary := false
+	for rows.Next() {
+		var date string
+		var value float64
+		var preliminary bool
+		if err := rows.Scan(&date, &value, &preliminary); err != nil {
+			return rec, false, err
+		}
+		t, err := time.Parse(dateFormat, date)
+		if err != nil {
+			return rec, false, fmt.Errorf("bad date %q in series %s: %w", date, id, err)
+		}
+		rec.Series.Dates = append(rec.Series.Dates, t)
+		rec.Series.Values = append(rec.Series.Values, value)
+		rec.Series.Preliminary = append(rec.Series.Preliminary, preliminary)
+		anyPreliminary = anyPreliminary || preliminary
+	}
+	if !anyPreliminary {
+		rec.Series.Preliminary = nil
+	}
+	return rec, true, rows.Err()
+}
+
+// SaveSeries replaces the stored history for a series.
+func (d *DB) SaveSeries(rec data.SeriesRecord) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO series (series_id, source, from_date, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (series_id) DO UPDATE SET
+			source = excluded.source, from_date = excluded.from_date, updated_at = excluded.updated_at`,
+		rec.ID, rec.Source, rec.From.UTC().Format(dateFormat), rec.UpdatedAt.UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM series_observations WHERE series_id = ?`, rec.ID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO series_observations (series_id, date, value, preliminary) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, date := range rec.Series.Dates {
+		if _, err := stmt.Exec(rec.ID, date.UTC().Format(dateFormat), rec.Series.Values[i], rec.Series.IsPreliminary(i)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadIndustryRD reads the most recently stored Damodaran dataset.
+func (d *DB) LoadIndustryRD() (data.IndustryRDRecord, bool, error) {
+	var rec data.IndustryRDRecord
+
+	var fetched string
+	err := d.db.QueryRow(`SELECT fetched_at FROM industry_rd ORDER BY fetched_at DESC LIMIT 1`).Scan(&fetched)
+	if err == sql.ErrNoRows {
+		return rec, false, nil
+	}
+	if err != nil {
+		return rec, false, err
+	}
+	rec.FetchedAt, _ = time.Parse(time.RFC3339, fetched)
+
+	rows, err := d.db.Query(`
+		SELECT industry, rd_intensity, COALESCE(firms, 0), COALESCE(revenue, 0), COALESCE(rd_expense, 0)
+		FROM industry_rd WHERE fetched_at = ?`, fetched)
+	if err != nil {
+		return rec, false, err
+	}
+	defer rows.Close()
+
+	rec.Industries = make(map[string]data.IndustryRD)
+	for rows.Next() {
+		var industry string
+		var v data.IndustryRD
+		if err := rows.Scan(&industry, &v.Intensity, &v.Firms, &v.Revenue, &v.RD); err != nil {
+			return rec, false, err
+		}
+		rec.Industries[industry] = v
+	}
+	return rec, true, rows.Err()
+}
+
+// SaveIndustryRD adds a Damodaran dataset, keeping earlier ones as history.
+// A dataset identical to the latest stored one is not stored again.
+func (d *DB) SaveIndustryRD(rec data.IndustryRDRecord) error {
+	if latest, ok, err := d.LoadIndustryRD(); err == nil && ok && sameValues(latest.Industries, rec.Industries) {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	fetched := rec.FetchedAt.UTC().Format(time.RFC3339)
+	for industry, v := range rec.Industries {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO industry_rd (industry, fetched_at, rd_intensity, firms, revenue, rd_expense)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			industry, fetched, v.Intensity, nullIfZero(float64(v.Firms)), nullIfZero(v.Revenue), nullIfZero(v.RD)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadRDArchive reads a stored Damodaran archive by year of publication.
+func (d *DB) LoadRDArchive(year int) (data.IndustryRDRecord, bool, error) {
+	var rec data.IndustryRDRecord
+
+	rows, err := d.db.Query(`
+		SELECT industry, rd_intensity, COALESCE(firms, 0), COALESCE(revenue, 0), COALESCE(rd_expense, 0), fetched_at
+		FROM industry_rd_archive WHERE year = ?`, year)
+	if err != nil {
+		return rec, false, err
+	}
+	defer rows.Close()
+
+	rec.Industries = make(map[string]data.IndustryRD)
+	for rows.Next() {
+		var industry, fetched string
+		var v data.IndustryRD
+		if err := rows.Scan(&industry, &v.Intensity, &v.Firms, &v.Revenue, &v.RD, &fetched); err != nil {
+			return rec, false, err
+		}
+		rec.Industries[industry] = v
+		rec.FetchedAt, _ = time.Parse(time.RFC3339, fetched)
+	}
+	return rec, len(rec.Industries) > 0, rows.Err()
+}
+
+// SaveRDArchive replaces the stored Damodaran archive for a year.
+func (d *DB) SaveRDArchive(year int, rec data.IndustryRDRecord) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM industry_rd_archive WHERE year = ?`, year); err != nil {
+		return err
+	}
+	fetched := rec.FetchedAt.UTC().Format(time.RFC3339)
+	for industry, v := range rec.Industries {
+		if _, err := tx.Exec(`
+			INSERT INTO industry_rd_archive (year, industry, rd_intensity, firms, revenue, rd_expense, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			year, industry, v.Intensity, nullIfZero(float64(v.Firms)), nullIfZero(v.Revenue), nullIfZero(v.RD), fetched); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadVintages reads the stored vintages of a FRED series.
+func (d *DB) LoadVintages(id string) ([]data.Vintage, bool, error) {
+	rows, err := d.db.Query(`
+		SELECT date, realtime_start, realtime_end, value
+		FROM series_vintages WHERE series_id = ? ORDER BY date, realtime_start`, id)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var vintages []data.Vintage
+	for rows.Next() {
+		var date, start, end string
+		var v data.Vintage
+		if err := rows.Scan(&date, &start, &end, &v.Value); err != nil {
+			return nil, false, err
+		}
+		v.Date, _ = time.Parse(dateFormat, date)
+		v.RealtimeStart, _ = time.Parse(dateFormat, start)
+		v.RealtimeEnd, _ = time.Parse(dateFormat, end)
+		vintages = append(vintages, v)
+	}
+	return vintages, len(vintages) > 0, rows.Err()
+}
+
+// SaveVintages replaces the stored vintages of a FRED series.
+func (d *DB) SaveVintages(id string, vintages []data.Vintage) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM series_vintages WHERE series_id = ?`, id); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO series_vintages (series_id, date, realtime_start, realtime_end, value)
+		VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, v := range vintages {
+		if _, err := stmt.Exec(id, v.Date.Format(dateFormat), v.RealtimeStart.Format(dateFormat),
+			v.RealtimeEnd.Format(dateFormat), v.Value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveScoreSnapshot records the scores computed by model from one data
+// refresh and returns the snapshot ID.
+func (d *DB) SaveScoreSnapshot(universe string, fetchedAt time.Time, model string, weights map[string]float64, scores []analysis.SectorScore) (int64, error) {
+	weightsJSON, err := json.Marshal(weights)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO score_snapshots (universe, computed_at, data_fetched_at, model, weights) VALUES (?, ?, ?, ?, ?)`,
+		universe, time.Now().UTC().Format(time.RFC3339), fetchedAt.UTC().Format(time.RFC3339), model, string(weightsJSON))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, s := range scores {
+		componentsJSON, err := json.Marshal(s.Components)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO sector_scores (
+				snapshot_id, sector, rank, opportunity_score,
+				momentum_score, valuation_score, growth_score, innovation_score, macro_score, quality_score, breadth_score,
+				factor_score, cycle_score, components,
+				price_return_3mo, price_return_6mo, price_return_12mo, relative_strength,
+				forward_pe, equity_risk_premium, employment_growth, rd_intensity
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, s.Sector, s.Rank, s.OpportunityScore,
+			s.Component("momentum"), s.Component("valuation"), s.Component("growth"), s.Component("innovation"),
+			s.Component("macro"), s.Component("quality"), s.Component("breadth"),
+			s.FactorScore, s.CycleScore, string(componentsJSON),
+			s.PriceReturn3Mo, s.PriceReturn6Mo, s.PriceReturn12Mo, s.RelativeStrength,
+			s.ForwardPE, s.EquityRiskPremium, s.EmploymentGrowth, s.RDIntensity); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// ERPHistory returns each sector's equity risk premium from a universe's
+// score snapshots, dated by when the scored data was fetched.
+func (d *DB) ERPHistory(universe string) (map[string]data.TimeSeries, error) {
+	rows, err := d.db.Query(`
+		SELECT s.sector, ss.data_fetched_at, s.equity_risk_premium
+		FROM sector_scores s JOIN score_snapshots ss ON ss.id = s.snapshot_id
+		WHERE ss.universe = ? AND s.equity_risk_premium IS NOT NULL
+		ORDER BY ss.data_fetched_at, ss.id`, universe)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string]data.TimeSeries)
+	for rows.Next() {
+		var sector, fetched string
+		var erp float64
+		if err := rows.Scan(&sector, &fetched, &erp); err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, fetched)
+		if err != nil {
+			continue
+		}
+
+		// Several snapshots of the same fetch keep the latest
+		ts := history[sector]
+		if n := len(ts.Dates); n > 0 && ts.Dates[n-1].Equal(t) {
+			ts.Values[n-1] = erp
+		} else {
+			ts.Dates = append(ts.Dates, t)
+			ts.Values = append(ts.Values, erp)
+		}
+		history[sector] = ts
+	}
+	return history, rows.Err()
+}
+
+// sameValues reports whether two industry maps hold the same figures.
+func sameValues(a, b map[string]data.IndustryRD) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// nullIfZero stores unset optional values as NULL.
+func nullIfZero(v float64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}